@@ -8,54 +8,137 @@ import (
 )
 
 func build(s *Ssg, o Outputs) ([]string, []OutputFile, error) {
+	if s.errInit != nil {
+		return nil, nil, s.errInit
+	}
+	err := s.preflight(false)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.build(o)
+}
+
+// build walks src after preflight, see [Ssg.Build]
+func (s *Ssg) build(o Outputs) ([]string, []OutputFile, error) {
 	s.result = buildOutput{
 		cacheOutput: s.options.caching,
 		writer:      o,
+		collisions:  s.options.collisions,
 	}
-	err := filepath.WalkDir(s.Src, s.walk)
+	err := s.collectLayouts()
 	if err != nil {
 		return nil, nil, err
 	}
+	err = s.collectIncludes()
+	if err != nil {
+		return nil, nil, err
+	}
+	err = s.collectRedirects()
+	if err != nil {
+		return nil, nil, err
+	}
+	s.tags = nil
+	s.errs = nil
+	s.indexDirs = nil
+	s.indexed = make(Set)
+	err = filepath.WalkDir(s.Src, s.walk)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Subdirectories are indexed before their parents
+	for i := len(s.indexDirs) - 1; i >= 0; i-- {
+		dir := s.indexDirs[i]
+		index, err := s.autoIndex(dir)
+		if err != nil {
+			err = s.fail(stageError(dir, StageCore, 0, fmt.Errorf("index error: %w", err)))
+			if err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		err = s.fail(s.addOutputs(dir, index...))
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	taxonomy, err := s.taxonomy()
+	if err != nil {
+		err = s.fail(stageError(filepath.Join(s.Src, s.options.tags), StageCore, 0, err))
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	err = s.fail(s.addOutputs(s.Src, taxonomy...))
+	if err != nil {
+		return nil, nil, err
+	}
+	redirects, err := s.redirectOutputs()
+	if err != nil {
+		err = s.fail(stageError(filepath.Join(s.Src, MarkerRedirects), StageCore, 0, err))
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	err = s.fail(s.addOutputs(s.Src, redirects...))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(s.errs) > 0 {
+		return s.result.files, s.result.cache, s.errs
+	}
 	return s.result.files, s.result.cache, nil
 }
 
 func (s *Ssg) walk(path string, d fs.DirEntry, err error) error {
 	if err != nil {
-		return err
+		return s.fail(stageError(path, StageRead, 0, err))
 	}
 	if d.IsDir() {
-		return s.collect(path)
+		if path == filepath.Join(s.Src, MarkerLayouts) || s.skipDsts.Contains(path) {
+			return fs.SkipDir
+		}
+		err := s.collect(path)
+		if err != nil {
+			// Files in dir cannot be built without its templates
+			err = s.fail(stageError(path, StageCollect, 0, err))
+			if err != nil {
+				return err
+			}
+			return fs.SkipDir
+		}
+		// Indexes are generated after the walk, when it is known
+		// which subdirectories have index pages to link to
+		s.indexDirs = append(s.indexDirs, path)
+		return nil
 	}
 
 	base := filepath.Base(path)
 	ignore, err := shouldIgnore(s.ssgignores, path, base, d)
 	if err != nil {
-		return err
+		return s.fail(stageError(path, StageRead, 0, err))
 	}
 	if ignore {
 		return nil
 	}
-
-	switch base {
+	// Templates and markers are never output, even if they are symlinks
+	switch {
 	case
-		MarkerHeader,
-		MarkerFooter,
-		MarkerSsgIgnore:
+		s.isTemplate(path),
+		path == filepath.Join(s.Src, MarkerRedirects),
+		path == filepath.Join(s.Src, MarkerLayouts):
 
 		return nil
 	}
+	if isSymlink(d) {
+		return s.walkSymlink(path)
+	}
 
 	data, err := ReadFile(path)
 	if err != nil {
-		return err
+		return s.fail(stageError(path, StageRead, 0, err))
 	}
 
-	// Remember input files for .files
-	//
-	// Original ssg does not include _header.html
-	// and _footer.html in .files
-	s.result.files = append(s.result.files, path)
-
+	input := path
 	skipCore := false
 	for i, p := range s.options.pipelines {
 		path, data, d, err = p(path, data, d)
@@ -69,17 +152,79 @@ func (s *Ssg) walk(path string, d fs.DirEntry, err error) error {
 		if errors.Is(err, ErrBreakPipelines) {
 			break
 		}
-		return fmt.Errorf("[pipeline %d] error: %w", i, err)
+		return s.fail(stageError(input, StagePipeline, i, err))
 	}
 
+	// Remember input files for .files
+	//
+	// Original ssg does not include _header.html
+	// and _footer.html in .files
 	if skipCore {
+		s.result.files = append(s.result.files, input)
+		return nil
+	}
+
+	outputs, err := s.core(path, data, d)
+	if err != nil {
+		var e *BuildError
+		if errors.As(err, &e) {
+			e.Path = input
+		}
+		return s.fail(stageError(input, StageCore, 0, err))
+	}
+
+	// Unpublished pages, e.g. drafts, are not in .files
+	if len(outputs) == 0 {
 		return nil
 	}
+	s.result.files = append(s.result.files, input)
 
-	output, err := s.core(path, data, d)
+	// Outputs originate from the input file, even if pipelines renamed it
+	for i := range outputs {
+		if outputs[i].originator == path {
+			outputs[i].originator = input
+		}
+	}
+	return s.fail(s.addOutputs(input, outputs...))
+}
+
+// addOutputs adds outputs built from path to the build result
+func (s *Ssg) addOutputs(path string, outputs ...OutputFile) error {
+	err := s.result.add(outputs...)
 	if err != nil {
-		return fmt.Errorf("core error: %w", err)
+		return stageError(path, StageOutput, 0, err)
+	}
+	for i := range outputs {
+		if filepath.Base(outputs[i].target) == "index.html" {
+			s.indexed.Insert(filepath.Dir(outputs[i].target))
+		}
 	}
-	s.result.Add(output)
 	return nil
 }
+
+// isTemplate returns whether path is a marker, template or included file,
+// which is never output on its own
+func (s *Ssg) isTemplate(path string) bool {
+	base := filepath.Base(path)
+	switch base {
+	case
+		MarkerHeader,
+		MarkerFooter,
+		MarkerHeaderGemini,
+		MarkerFooterGemini,
+		MarkerHeaderNone,
+		MarkerFooterNone,
+		MarkerHeaderDefault,
+		MarkerFooterDefault,
+		MarkerConfig,
+		MarkerSsgIgnore:
+
+		return true
+	}
+	_, pageHeader := s.pageHeaders[path]
+	_, pageFooter := s.pageFooters[path]
+	return pageHeader ||
+		pageFooter ||
+		isSlot(base) ||
+		s.included.Contains(path)
+}
@@ -1,25 +1,67 @@
 package main
 
 import (
+	"flag"
 	"os"
 	"syscall"
 
 	"github.com/soyart/ssg-go"
 )
 
+const usage = `usage:
+  ssg [-drafts] src dst title base_url
+  ssg build -m manifest.json [-c] [-drafts]
+`
+
 func main() {
-	if len(os.Args) < 5 {
-		ssg.Fprint(os.Stdout, "usage: ssg src dst title base_url\n")
+	if len(os.Args) > 1 && os.Args[1] == "build" {
+		build(os.Args[2:])
+		return
+	}
+
+	flags := flag.NewFlagSet("ssg", flag.ExitOnError)
+	drafts := flags.Bool("drafts", false, "include drafts and scheduled pages")
+	_ = flags.Parse(os.Args[1:])
+
+	args := flags.Args()
+	if len(args) < 4 {
+		ssg.Fprint(os.Stdout, usage)
 		syscall.Exit(1)
 	}
 
-	src, dst, title, url := os.Args[1], os.Args[2], os.Args[3], os.Args[4]
+	src, dst, title, url := args[0], args[1], args[2], args[3]
 	err := ssg.Generate(
 		src, dst, title, url,
 		ssg.WritersFromEnv(),
+		ssg.Drafts(*drafts),
 	)
 	if err != nil {
 		ssg.Fprintln(os.Stdout, "error with", "src", src, "dst", dst, "title", title, "url", url)
 		panic(err)
 	}
 }
+
+// build builds all sites listed in a manifest
+func build(args []string) {
+	flags := flag.NewFlagSet("build", flag.ExitOnError)
+	manifest := flags.String("m", "", "path to manifest JSON")
+	concurrent := flags.Bool("c", false, "build sites concurrently")
+	drafts := flags.Bool("drafts", false, "include drafts and scheduled pages")
+	_ = flags.Parse(args)
+
+	if *manifest == "" {
+		ssg.Fprint(os.Stdout, usage)
+		syscall.Exit(1)
+	}
+
+	m, err := ssg.ParseManifest(*manifest)
+	if err != nil {
+		ssg.Fprintln(os.Stdout, "error with", "manifest", *manifest)
+		panic(err)
+	}
+	err = m.Build(*concurrent, ssg.WritersFromEnv(), ssg.Drafts(*drafts))
+	if err != nil {
+		ssg.Fprintln(os.Stdout, "error with", "manifest", *manifest)
+		panic(err)
+	}
+}
@@ -11,7 +11,6 @@ import (
 	"strings"
 	"sync"
 
-	"github.com/gomarkdown/markdown"
 	"github.com/gomarkdown/markdown/html"
 	"github.com/gomarkdown/markdown/parser"
 )
@@ -21,6 +20,21 @@ const (
 	MarkerFooter    = "_footer.html"
 	MarkerSsgIgnore = ".ssgignore"
 
+	// MarkerHeaderNone and MarkerFooterNone stop header or footer inheritance,
+	// i.e. pages under the directory have no header or footer
+	MarkerHeaderNone = "_header.none"
+	MarkerFooterNone = "_footer.none"
+
+	// MarkerHeaderDefault and MarkerFooterDefault reset header or footer
+	// under the directory to HeaderDefault or FooterDefault
+	MarkerHeaderDefault = "_header.default"
+	MarkerFooterDefault = "_footer.default"
+
+	// ExtPageHeader and ExtPageFooter are extensions of per-page header
+	// and footer overrides, e.g. foo.header.html for foo.md
+	ExtPageHeader = ".header.html"
+	ExtPageFooter = ".footer.html"
+
 	WritersEnvKey      = "SSG_WRITERS"
 	WritersDefault int = 20
 
@@ -55,16 +69,30 @@ var (
 type (
 	Set map[string]struct{}
 
-	// perDir tracks files under directory in a trie-like fashion.
-	// Used to choose headers and footers.
+	// perDir tracks values for directories in a path trie keyed on
+	// cleaned path components, e.g. "foo/bar" is stored under "foo" -> "bar".
+	// Used to choose headers, footers and other cascading values.
 	perDir[T any] struct {
 		defaultValue T
-		values       map[string]T
+		root         *trieNode[T]
+	}
+
+	trieNode[T any] struct {
+		children map[string]*trieNode[T]
+		value    T
+		dir      string // Path the value was added with
+		ok       bool   // Whether the node has value
 	}
 
 	header struct {
 		*bytes.Buffer
 		titleFrom TitleFrom
+		source    string // Template file, empty for default header
+	}
+
+	footer struct {
+		*bytes.Buffer
+		source string // Template file, empty for default footer
 	}
 
 	headers struct {
@@ -72,19 +100,10 @@ type (
 	}
 
 	footers struct {
-		perDir[*bytes.Buffer]
+		perDir[footer]
 	}
 )
 
-// ToHTML converts md (Markdown) into HTML document
-func ToHTML(md []byte) []byte {
-	root := markdown.Parse(md, parser.NewWithExtensions(SsgExtensions))
-	renderer := html.NewRenderer(html.RendererOptions{
-		Flags: HTMLFlags,
-	})
-	return markdown.Render(root, renderer)
-}
-
 func FileIs(f os.FileInfo, mode fs.FileMode) bool {
 	return f.Mode()&mode != 0
 }
@@ -110,25 +129,22 @@ func (s Set) Contains(items ...string) bool {
 	return true
 }
 
+// Fprint is [fmt.Fprint] for writers that never fail, e.g. [bytes.Buffer].
+// Write errors are ignored; use [fmt.Fprint] to handle them.
 func Fprint(w io.Writer, data ...any) {
-	_, err := fmt.Fprint(w, data...)
-	if err != nil {
-		panic(err)
-	}
+	_, _ = fmt.Fprint(w, data...)
 }
 
+// Fprintf is [fmt.Fprintf] for writers that never fail, e.g. [bytes.Buffer].
+// Write errors are ignored; use [fmt.Fprintf] to handle them.
 func Fprintf(w io.Writer, format string, data ...any) {
-	_, err := fmt.Fprintf(w, format, data...)
-	if err != nil {
-		panic(err)
-	}
+	_, _ = fmt.Fprintf(w, format, data...)
 }
 
+// Fprintln is [fmt.Fprintln] for writers that never fail, e.g. [bytes.Buffer].
+// Write errors are ignored; use [fmt.Fprintln] to handle them.
 func Fprintln(w io.Writer, data ...any) {
-	_, err := fmt.Fprintln(w, data...)
-	if err != nil {
-		panic(err)
-	}
+	_, _ = fmt.Fprintln(w, data...)
 }
 
 func ReadFile(path string) ([]byte, error) {
@@ -156,6 +172,11 @@ func (o *OutputFile) Data() []byte {
 	return o.data
 }
 
+// Symlink returns the link target if o is a symlink, or an empty string
+func (o *OutputFile) Symlink() string {
+	return o.symlink
+}
+
 func (o *OutputFile) Perm() fs.FileMode {
 	if o.perm == fs.FileMode(0) {
 		return fs.ModePerm
@@ -163,6 +184,23 @@ func (o *OutputFile) Perm() fs.FileMode {
 	return o.perm
 }
 
+// write writes o to its target, creating parent directories as needed.
+// Symlinks replace any existing file at target.
+func (o *OutputFile) write() error {
+	err := os.MkdirAll(filepath.Dir(o.target), os.ModePerm)
+	if err != nil {
+		return err
+	}
+	if o.symlink == "" {
+		return os.WriteFile(o.target, o.data, o.Perm())
+	}
+	err = os.Remove(o.target)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Symlink(o.symlink, o.target)
+}
+
 // WriteOutSlice blocks and writes concurrently from writes to their output locations.
 func WriteOutSlice(writes []OutputFile, concurrent int) error {
 	if concurrent == 0 {
@@ -170,8 +208,9 @@ func WriteOutSlice(writes []OutputFile, concurrent int) error {
 	}
 
 	wg := new(sync.WaitGroup)
-	errs := make(chan errorWrite)
 	guard := make(chan struct{}, concurrent)
+	mut := new(sync.Mutex) // Guards wErrs
+	var wErrs BuildErrors
 
 	for i := range writes {
 		guard <- struct{}{}
@@ -183,22 +222,16 @@ func WriteOutSlice(writes []OutputFile, concurrent int) error {
 				wg.Done()
 			}()
 
-			err := os.MkdirAll(filepath.Dir(w.target), os.ModePerm)
-			if err != nil {
-				errs <- errorWrite{
-					err:        err,
-					target:     w.target,
-					originator: w.originator,
-				}
-				return
-			}
-			err = os.WriteFile(w.target, w.data, w.Perm())
+			err := w.write()
 			if err != nil {
-				errs <- errorWrite{
-					err:        err,
-					target:     w.target,
-					originator: w.originator,
-				}
+				mut.Lock()
+				defer mut.Unlock()
+				wErrs = append(wErrs, &BuildError{
+					Path:   w.originator,
+					Stage:  StageWrite,
+					Target: w.target,
+					Err:    err,
+				})
 				return
 			}
 
@@ -206,17 +239,9 @@ func WriteOutSlice(writes []OutputFile, concurrent int) error {
 		}(&writes[i], wg)
 	}
 
-	go func() {
-		wg.Wait()
-		close(errs)
-	}()
-
-	var wErrs []error
-	for err := range errs { // Blocks here until errs is closed
-		wErrs = append(wErrs, err)
-	}
+	wg.Wait()
 	if len(wErrs) > 0 {
-		return errors.Join(wErrs...)
+		return wErrs
 	}
 
 	return nil
@@ -233,69 +258,97 @@ func newHeaders(defaultHeader string) headers {
 
 func newFooters(defaultFooter string) footers {
 	return footers{
-		perDir: newPerDir(bytes.NewBufferString(defaultFooter)),
+		perDir: newPerDir(footer{
+			Buffer: bytes.NewBufferString(defaultFooter),
+		}),
 	}
 }
 
 func newPerDir[T any](defaultValue T) perDir[T] {
 	return perDir[T]{
 		defaultValue: defaultValue,
-		values:       make(map[string]T),
+		root:         &trieNode[T]{},
 	}
 }
 
 func (p *perDir[T]) add(path string, v T) error {
-	_, ok := p.values[path]
-	if ok {
+	node := p.root
+	for _, part := range pathComponents(path) {
+		child, ok := node.children[part]
+		if !ok {
+			if node.children == nil {
+				node.children = make(map[string]*trieNode[T])
+			}
+			child = &trieNode[T]{}
+			node.children[part] = child
+		}
+		node = child
+	}
+	if node.ok {
 		return fmt.Errorf("found duplicate path '%s'", path)
 	}
 
-	p.values[path] = v
+	node.value, node.dir, node.ok = v, path, true
 	return nil
 }
 
+// get returns value added exactly for path
+func (p *perDir[T]) get(path string) (T, bool) {
+	node := p.root
+	for _, part := range pathComponents(path) {
+		child, ok := node.children[part]
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		node = child
+	}
+	return node.value, node.ok
+}
+
+// choose chooses which value should be used for the given path,
+// i.e. the value of path itself or of its nearest ancestor.
+// If no value is found, choose returns the default value.
 func (p *perDir[T]) choose(path string) T {
-	return choose(path, p.defaultValue, p.values)
+	v, _ := p.resolve(path)
+	return v
 }
 
-// choose chooses which map value should be used for the given path.
-func choose[T any](path string, valueDefault T, m map[string]T) T {
-	chosen, ok := m[path]
-	if ok {
-		return chosen
+// resolve is like choose, but also returns the path
+// the chosen value was added with. The path is empty
+// if the default value is chosen.
+func (p *perDir[T]) resolve(path string) (T, string) {
+	chosen, dir := p.defaultValue, ""
+	node := p.root
+	if node.ok {
+		chosen, dir = node.value, node.dir
 	}
-	parts := strings.Split(path, "/")
-	chosen, max := valueDefault, 0
-
-outer:
-	for prefix, stored := range m {
-		prefixes := strings.Split(prefix, "/")
-		for i := range parts {
-			if i >= len(prefixes) {
-				break
-			}
-			if parts[i] != prefixes[i] {
-				continue outer
-			}
+	for _, part := range pathComponents(path) {
+		child, ok := node.children[part]
+		if !ok {
+			break
 		}
-
-		l := len(prefix)
-		if max > l {
-			continue
+		node = child
+		if node.ok {
+			chosen, dir = node.value, node.dir
 		}
-
-		chosen, max = stored, l
 	}
-
-	return chosen
+	return chosen, dir
 }
 
-type errorWrite struct {
-	err        error
-	target     string
-	originator string
-}
-
-func (e errorWrite) Error() string {
-	return fmt.Errorf("WriteError(target='%s',originator='%s'): %w", e.target, e.originator, e.err).Error()
+// pathComponents splits cleaned path into its components.
+// Absolute paths have "/" as their first component.
+func pathComponents(path string) []string {
+	path = filepath.ToSlash(filepath.Clean(path))
+	var parts []string
+	if strings.HasPrefix(path, "/") {
+		parts = append(parts, "/")
+	}
+	for _, part := range strings.Split(path, "/") {
+		if part == "" || part == "." {
+			continue
+		}
+		parts = append(parts, part)
+	}
+	return parts
 }
@@ -15,6 +15,8 @@ func TestPerDir(t *testing.T) {
 	pd.add("/10/20", 20)
 	pd.add("/10/20/30", 30)
 
+	pd.add("/foo/bar", 100)
+
 	tests := []testCase{
 		{
 			path:     "/",
@@ -56,13 +58,70 @@ func TestPerDir(t *testing.T) {
 			path:     "/10/20/30/foo/bar/baz/1",
 			expected: 30,
 		},
+		{
+			path:     "/10",
+			expected: 0,
+		},
+		{
+			path:     "/1/20",
+			expected: 1,
+		},
+		{
+			path:     "/10/2",
+			expected: 0,
+		},
+		{
+			path:     "/foo",
+			expected: 0,
+		},
+		{
+			path:     "/foo/bar/../baz",
+			expected: 0,
+		},
+		{
+			path:     "/foo//bar/",
+			expected: 100,
+		},
+		{
+			path:     "/foo/barbaz/x",
+			expected: 0,
+		},
 	}
 
 	for i := range tests {
 		tc := &tests[i]
-		actual := choose(tc.path, defaultValue, pd.values)
+		actual := pd.choose(tc.path)
 		if tc.expected != actual {
 			t.Fatalf("unexpected value %v, expecting %v for path '%s'", actual, tc.expected, tc.path)
 		}
 	}
 }
+
+func TestPerDirRelative(t *testing.T) {
+	pd := newPerDir("default")
+	pd.add("src", "src")
+	pd.add("src/blog", "blog")
+
+	if err := pd.add("./src/blog/", "duplicate"); err == nil {
+		t.Fatalf("unexpected nil error for duplicate path")
+	}
+
+	tests := map[string]string{
+		"src":                 "src",
+		"./src/index.md":      "src",
+		"src/blog":            "blog",
+		"src/blog/2023/a.md":  "blog",
+		"src/blogs/a.md":      "src",
+		"/src/blog":           "default",
+		"other/src/blog/a.md": "default",
+	}
+	for path, expected := range tests {
+		actual, dir := pd.resolve(path)
+		if actual != expected {
+			t.Fatalf("unexpected value '%s' for path '%s', expecting '%s'", actual, path, expected)
+		}
+		if actual == "default" && dir != "" {
+			t.Fatalf("unexpected dir '%s' for default value", dir)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package ssg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gomarkdown/markdown/html"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+// MarkerConfig is the per-directory configuration file.
+// Its values cascade down the directory tree, with each field
+// overriding the same field from the ancestors' configuration.
+const MarkerConfig = "_ssg.json"
+
+var (
+	markdownExtensions = map[string]parser.Extensions{
+		"common":                     parser.CommonExtensions,
+		"ssg":                        SsgExtensions,
+		"no-intra-emphasis":          parser.NoIntraEmphasis,
+		"tables":                     parser.Tables,
+		"fenced-code":                parser.FencedCode,
+		"autolink":                   parser.Autolink,
+		"strikethrough":              parser.Strikethrough,
+		"lax-html-blocks":            parser.LaxHTMLBlocks,
+		"space-headings":             parser.SpaceHeadings,
+		"hard-line-break":            parser.HardLineBreak,
+		"non-blocking-space":         parser.NonBlockingSpace,
+		"tab-size-eight":             parser.TabSizeEight,
+		"footnotes":                  parser.Footnotes,
+		"no-empty-line-before-block": parser.NoEmptyLineBeforeBlock,
+		"heading-ids":                parser.HeadingIDs,
+		"titleblock":                 parser.Titleblock,
+		"auto-heading-ids":           parser.AutoHeadingIDs,
+		"backslash-line-break":       parser.BackslashLineBreak,
+		"definition-lists":           parser.DefinitionLists,
+		"math-jax":                   parser.MathJax,
+		"ordered-list-start":         parser.OrderedListStart,
+		"attributes":                 parser.Attributes,
+		"super-subscript":            parser.SuperSubscript,
+		"empty-lines-break-list":     parser.EmptyLinesBreakList,
+		"mmark":                      parser.Mmark,
+	}
+
+	markdownFlags = map[string]html.Flags{
+		"common":                    html.CommonFlags,
+		"skip-html":                 html.SkipHTML,
+		"skip-images":               html.SkipImages,
+		"skip-links":                html.SkipLinks,
+		"safelink":                  html.Safelink,
+		"nofollow-links":            html.NofollowLinks,
+		"noreferrer-links":          html.NoreferrerLinks,
+		"noopener-links":            html.NoopenerLinks,
+		"href-target-blank":         html.HrefTargetBlank,
+		"footnote-return-links":     html.FootnoteReturnLinks,
+		"smartypants":               html.Smartypants,
+		"smartypants-fractions":     html.SmartypantsFractions,
+		"smartypants-dashes":        html.SmartypantsDashes,
+		"smartypants-latex-dashes":  html.SmartypantsLatexDashes,
+		"smartypants-angled-quotes": html.SmartypantsAngledQuotes,
+		"smartypants-quotes-nbsp":   html.SmartypantsQuotesNBSP,
+		"lazy-load-images":          html.LazyLoadImages,
+	}
+)
+
+type (
+	// DirConfig is the configuration read from [MarkerConfig].
+	// Nil fields are inherited from the parent directories.
+	DirConfig struct {
+		// Title is the default page title
+		Title *string `json:"title"`
+
+		// TitleFrom overrides the title source of the headers,
+		// one of "h1", "tag" or "none"
+		TitleFrom *TitleFrom `json:"title-from"`
+
+		// Markdown overrides the Markdown dialect
+		Markdown *MarkdownConfig `json:"markdown"`
+
+		// Sitemap sets whether outputs are listed in sitemap.xml
+		Sitemap *bool `json:"sitemap"`
+
+		// Layout is the default named layout for pages.
+		// Empty string resets to cascading templates.
+		Layout *string `json:"layout"`
+
+		// Index sets whether index.html is generated
+		// for directories without an index page
+		Index *bool `json:"index"`
+
+		// IndexSort is the page order of generated indexes,
+		// one of "name", "title" or "date"
+		IndexSort *IndexSort `json:"index-sort"`
+
+		// IndexPageSize is the maximum number of entries per generated index page.
+		// Zero disables pagination.
+		IndexPageSize *int `json:"index-page-size"`
+
+		// Draft marks pages as drafts
+		Draft *bool `json:"draft"`
+
+		// Permalink is the output path pattern for pages other than index pages,
+		// e.g. "/:year/:month/:slug/". Empty string resets to mirrored paths.
+		Permalink *string `json:"permalink"`
+	}
+
+	// MarkdownConfig is the JSON representation of [Markdown],
+	// with extensions and flags as lists of names, e.g.
+	// {"extensions": ["ssg", "footnotes"], "flags": ["common", "href-target-blank"]}
+	MarkdownConfig struct {
+		Extensions    []string `json:"extensions"`
+		Flags         []string `json:"flags"`
+		HeadingAnchor *string  `json:"heading-anchor"`
+		TOCDepth      *int     `json:"toc-depth"`
+	}
+)
+
+// ParseDirConfig parses and validates data as [DirConfig]
+func ParseDirConfig(data []byte) (DirConfig, error) {
+	var c DirConfig
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&c)
+	if err != nil {
+		return DirConfig{}, err
+	}
+	if c.IndexPageSize != nil && *c.IndexPageSize < 0 {
+		return DirConfig{}, fmt.Errorf("negative index-page-size %d", *c.IndexPageSize)
+	}
+	if c.Markdown != nil {
+		_, err = c.Markdown.apply(Markdown{})
+		if err != nil {
+			return DirConfig{}, err
+		}
+	}
+	if c.Permalink != nil {
+		err = validatePermalink(*c.Permalink)
+		if err != nil {
+			return DirConfig{}, err
+		}
+	}
+	return c, nil
+}
+
+// merge returns c with nil fields inherited from parent
+func (c DirConfig) merge(parent DirConfig) DirConfig {
+	if c.Title == nil {
+		c.Title = parent.Title
+	}
+	if c.TitleFrom == nil {
+		c.TitleFrom = parent.TitleFrom
+	}
+	if c.Sitemap == nil {
+		c.Sitemap = parent.Sitemap
+	}
+	if c.Layout == nil {
+		c.Layout = parent.Layout
+	}
+	if c.Index == nil {
+		c.Index = parent.Index
+	}
+	if c.IndexSort == nil {
+		c.IndexSort = parent.IndexSort
+	}
+	if c.IndexPageSize == nil {
+		c.IndexPageSize = parent.IndexPageSize
+	}
+	if c.Draft == nil {
+		c.Draft = parent.Draft
+	}
+	if c.Permalink == nil {
+		c.Permalink = parent.Permalink
+	}
+	switch {
+	case c.Markdown == nil:
+		c.Markdown = parent.Markdown
+	case parent.Markdown != nil:
+		m := c.Markdown.merge(*parent.Markdown)
+		c.Markdown = &m
+	}
+	return c
+}
+
+func (m MarkdownConfig) merge(parent MarkdownConfig) MarkdownConfig {
+	if m.Extensions == nil {
+		m.Extensions = parent.Extensions
+	}
+	if m.Flags == nil {
+		m.Flags = parent.Flags
+	}
+	if m.HeadingAnchor == nil {
+		m.HeadingAnchor = parent.HeadingAnchor
+	}
+	if m.TOCDepth == nil {
+		m.TOCDepth = parent.TOCDepth
+	}
+	return m
+}
+
+// apply returns base with fields overridden by m
+func (m MarkdownConfig) apply(base Markdown) (Markdown, error) {
+	if m.Extensions != nil {
+		base.Extensions = parser.NoExtensions
+		for _, name := range m.Extensions {
+			ext, ok := markdownExtensions[name]
+			if !ok {
+				return Markdown{}, fmt.Errorf("unknown markdown extension '%s'", name)
+			}
+			base.Extensions |= ext
+		}
+	}
+	if m.Flags != nil {
+		base.Flags = html.FlagsNone
+		for _, name := range m.Flags {
+			flag, ok := markdownFlags[name]
+			if !ok {
+				return Markdown{}, fmt.Errorf("unknown markdown flag '%s'", name)
+			}
+			base.Flags |= flag
+		}
+	}
+	if m.HeadingAnchor != nil {
+		base.HeadingAnchor = *m.HeadingAnchor
+	}
+	if m.TOCDepth != nil {
+		base.TOCDepth = *m.TOCDepth
+	}
+	return base, nil
+}
+
+// collectConfig parses config data for dir,
+// and stores it merged with the config inherited by dir
+func (s *Ssg) collectConfig(dir string, data []byte) error {
+	c, err := ParseDirConfig(data)
+	if err != nil {
+		return fmt.Errorf("bad %s in %s: %w", MarkerConfig, dir, err)
+	}
+	return s.configs.add(dir, c.merge(s.configs.choose(dir)))
+}
+
+// title returns default title for pages under path
+func (s *Ssg) title(path string) []byte {
+	c := s.configs.choose(path)
+	if c.Title != nil {
+		return []byte(*c.Title)
+	}
+	return []byte(s.Title)
+}
+
+// markdown returns Markdown dialect for pages under path
+func (s *Ssg) markdown(path string) Markdown {
+	c := s.configs.choose(path)
+	if c.Markdown == nil {
+		return s.options.markdown
+	}
+	// Already validated in collectConfig
+	m, _ := c.Markdown.apply(s.options.markdown)
+	return m
+}
+
+// sitemap reports whether outputs from path are included in sitemap
+func (s *Ssg) sitemap(path string) bool {
+	c := s.configs.choose(path)
+	return c.Sitemap == nil || *c.Sitemap
+}
@@ -0,0 +1,131 @@
+package ssg_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soyart/ssg-go"
+)
+
+func TestDirConfig(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	files := map[string]string{
+		"index.md": "# Home\n\nSome \"quote\"",
+		"blog/_ssg.json": `{
+			"title": "Blog",
+			"title-from": "tag",
+			"markdown": {"flags": ["href-target-blank"], "heading-anchor": "#"}
+		}`,
+		"blog/post.md":             ":ssg-title Post title\n\n# Post\n\n[link](https://example.com) \"quote\"",
+		"blog/untitled.md":         "# Untitled h1",
+		"blog/drafts/_ssg.json":    `{"sitemap": false, "markdown": {"heading-anchor": ""}}`,
+		"blog/drafts/draft.md":     "# Draft\n\n[link](https://example.com)",
+		"notes/_ssg.json":          `{"markdown": {"extensions": ["ssg", "hard-line-break"]}}`,
+		"notes/note.md":            "# Note\nline 1\nline 2",
+		"notes/drafts/style.css":   "body {}",
+		"notes/drafts/_ssg.json":   `{"sitemap": false}`,
+		"notes/drafts/nohide.html": "<p>no hide</p>",
+	}
+	for name, content := range files {
+		path := filepath.Join(src, name)
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, outputs, err := ssg.Build(src, dst, "TestDirConfig", "https://example.com", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expecteds := map[string][]string{
+		"index.html": {
+			"<title>Home</title>",
+			"&ldquo;quote&rdquo;",
+		},
+		"blog/post.html": {
+			"<title>Post title</title>",
+			`<h1 id="post">Post <a class="ssg-anchor" href="#post">#</a></h1>`,
+			`<a href="https://example.com" target="_blank">link</a> &quot;quote&quot;`,
+		},
+		"blog/untitled.html": {
+			"<title>Blog</title>",
+		},
+		"blog/drafts/draft.html": {
+			`<h1 id="draft">Draft</h1>`,
+			`target="_blank"`,
+		},
+		"notes/note.html": {
+			"<title>Note</title>",
+			"line 1<br>\nline 2",
+		},
+	}
+	for i := range outputs {
+		o := &outputs[i]
+		rel, err := filepath.Rel(dst, o.Target())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range expecteds[rel] {
+			if !strings.Contains(string(o.Data()), s) {
+				t.Fatalf("missing expected substr '%s' from '%s':\n%s", s, rel, o.Data())
+			}
+		}
+		delete(expecteds, rel)
+	}
+	if len(expecteds) != 0 {
+		t.Fatalf("missing outputs %v", expecteds)
+	}
+
+	sitemap, err := ssg.Sitemap(dst, "https://example.com", time.Now(), outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sitemap, "drafts/") {
+		t.Fatalf("unexpected drafts in sitemap:\n%s", sitemap)
+	}
+	if !strings.Contains(sitemap, "blog/post.html") {
+		t.Fatalf("missing blog/post.html in sitemap:\n%s", sitemap)
+	}
+}
+
+func TestParseDirConfig(t *testing.T) {
+	invalids := []string{
+		`{"foo": "bar"}`,
+		`{"title-from": "h2"}`,
+		`{"markdown": {"extensions": ["unknown"]}}`,
+		`{"markdown": {"flags": ["unknown"]}}`,
+		`{"sitemap": "false"}`,
+		`{"permalink": "/:year/:title/"}`,
+	}
+	for _, data := range invalids {
+		_, err := ssg.ParseDirConfig([]byte(data))
+		if err == nil {
+			t.Fatalf("unexpected nil error for config %s", data)
+		}
+	}
+
+	c, err := ssg.ParseDirConfig([]byte(`{"permalink": "/:yaer/:slug/"}`))
+	if !errors.Is(err, ssg.ErrPermalink) {
+		t.Fatalf("unexpected error for unknown permalink placeholder: %v", err)
+	}
+
+	c, err = ssg.ParseDirConfig([]byte(`{"title": "foo", "title-from": "none"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *c.Title != "foo" || *c.TitleFrom != ssg.TitleFromNone || c.Sitemap != nil {
+		t.Fatalf("unexpected config %+v", c)
+	}
+}
@@ -0,0 +1,127 @@
+package ssg
+
+import (
+	"bytes"
+	"html"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ExtFragment is the extension of HTML fragment files.
+// Fragments are HTML body content assembled with cascading
+// headers and footers, i.e. foo.frag.html -> foo.html
+const ExtFragment = ".frag.html"
+
+var (
+	reH1   = regexp.MustCompile(`(?is)<h1(?:\s[^>]*)?>(.*?)</h1>`)
+	reTags = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+type (
+	// Converter converts data read from path into HTML body content.
+	//
+	// The converted HTML is then assembled with cascading
+	// _header.html and _footer.html by ssg-go core.
+	Converter interface {
+		Convert(path string, data []byte) (html []byte, meta Meta, err error)
+	}
+
+	// ConverterFunc is an adapter to allow the use of ordinary functions
+	// as [Converter].
+	ConverterFunc func(path string, data []byte) ([]byte, Meta, error)
+
+	// Meta is the page metadata reported by a [Converter]
+	Meta struct {
+		// Title is used as the document head title
+		// if the header has TargetFromH1 placeholder.
+		// If empty, ssg-go falls back to the default title.
+		Title []byte
+	}
+
+	// Preformatted converts plain text into a HTML <pre> block.
+	// Its title is taken from the first non-empty line.
+	Preformatted struct{}
+
+	// Fragment passes HTML body content through unchanged.
+	// Its title is taken from the text of the first <h1> element.
+	Fragment struct{}
+)
+
+func (f ConverterFunc) Convert(path string, data []byte) ([]byte, Meta, error) {
+	return f(path, data)
+}
+
+// Convert converts Markdown in data into HTML.
+// The first Markdown h1 is reported as the page title.
+func (m Markdown) Convert(_ string, data []byte) ([]byte, Meta, error) {
+	return m.ToHTML(data), Meta{Title: GetTitleFromH1(data)}, nil
+}
+
+func (Preformatted) Convert(_ string, data []byte) ([]byte, Meta, error) {
+	var title []byte
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		line = bytes.TrimSpace(line)
+		if len(line) != 0 {
+			title = line
+			break
+		}
+	}
+
+	buf := bytes.NewBufferString("<pre>")
+	buf.WriteString(html.EscapeString(string(data)))
+	buf.WriteString("</pre>\n")
+	return buf.Bytes(), Meta{Title: title}, nil
+}
+
+func (Fragment) Convert(_ string, data []byte) ([]byte, Meta, error) {
+	return data, Meta{Title: GetTitleFromHTML(data)}, nil
+}
+
+// IsFragment reports whether path is an HTML fragment file
+func IsFragment(path string) bool {
+	base := filepath.Base(path)
+	return strings.HasSuffix(base, ExtFragment) && base != ExtFragment
+}
+
+// GetTitleFromHTML returns text content of the first <h1> element in data
+func GetTitleFromHTML(data []byte) []byte {
+	match := reH1.FindSubmatch(data)
+	if match == nil {
+		return nil
+	}
+	title := reTags.ReplaceAll(match[1], nil)
+	return bytes.TrimSpace(title)
+}
+
+// converter returns the Converter registered for path,
+// and the extension (suffix) it was registered with.
+// Registered suffixes are matched longest first, so that
+// ".foo.html" wins over ".html". Markdown files are converted
+// with markdown and fragments with [Fragment],
+// unless another converter is registered for them.
+//
+// If no converter is found, converter returns nil Converter.
+func (o *options) converter(path string, markdown Markdown) (string, Converter) {
+	base := filepath.Base(path)
+	ext, c := "", Converter(nil)
+	for suffix, conv := range o.converters {
+		if !strings.HasSuffix(base, suffix) || len(suffix) <= len(ext) {
+			continue
+		}
+		if len(suffix) == len(base) {
+			continue
+		}
+		ext, c = suffix, conv
+	}
+	if c != nil {
+		return ext, c
+	}
+	switch {
+	case IsFragment(base):
+		return ExtFragment, Fragment{}
+	case filepath.Ext(base) == ".md":
+		return ".md", markdown
+	}
+	return "", nil
+}
@@ -0,0 +1,109 @@
+package ssg_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/soyart/ssg-go"
+)
+
+func TestConverters(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	files := map[string]string{
+		"index.md":       "# Markdown page\n\nSome para\n",
+		"notes.txt":      "Some notes\n<b>not bold</b>\n",
+		"upper.up.txt":   "# shout\n",
+		"style.css":      "body {}\n",
+		"preferred.html": "<p>full html</p>\n",
+		"preferred.txt":  "should be copied\n",
+	}
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(src, name), []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	upper := ssg.ConverterFunc(func(_ string, data []byte) ([]byte, ssg.Meta, error) {
+		return bytes.ToUpper(data), ssg.Meta{Title: []byte("Upper")}, nil
+	})
+
+	_, outputs, err := ssg.Build(src, dst, "TestConverters", "https://example.com", nil,
+		ssg.WithConverter(".txt", ssg.Preformatted{}),
+		ssg.WithConverter(".up.txt", upper),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expecteds := map[string][]string{
+		"index.html": {
+			"<title>Markdown page</title>",
+			`<h1 id="markdown-page">Markdown page</h1>`,
+		},
+		"notes.html": {
+			"<title>Some notes</title>",
+			"<pre>Some notes\n&lt;b&gt;not bold&lt;/b&gt;\n</pre>",
+		},
+		"upper.html": {
+			"<title>Upper</title>",
+			"# SHOUT",
+		},
+		"style.css": {
+			"body {}",
+		},
+		"preferred.html": {
+			"<p>full html</p>",
+		},
+		"preferred.txt": {
+			"should be copied",
+		},
+	}
+
+	if len(outputs) != len(expecteds) {
+		t.Fatalf("unexpected number of outputs: expected=%d, actual=%d", len(expecteds), len(outputs))
+	}
+
+	for i := range outputs {
+		o := &outputs[i]
+		rel, err := filepath.Rel(dst, o.Target())
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, ok := expecteds[rel]
+		if !ok {
+			t.Fatalf("unexpected output '%s' from '%s'", rel, o.Originator())
+		}
+		for _, s := range expected {
+			if !strings.Contains(string(o.Data()), s) {
+				t.Fatalf("missing expected substr '%s' from output '%s':\n%s", s, rel, o.Data())
+			}
+		}
+	}
+}
+
+func TestGetTitleFromHTML(t *testing.T) {
+	tests := map[string]string{
+		"":                                      "",
+		"<p>no h1</p>":                          "",
+		"<h1>Title</h1>":                        "Title",
+		`<h1 id="foo" class="bar">Title</h1>`:   "Title",
+		"<h2>Not</h2>\n<H1>\n  Upper\n</H1>":    "Upper",
+		"<h1>Some <em>nested</em> title</h1>":   "Some nested title",
+		"<h1>First</h1><h1>Second</h1>":         "First",
+		"<header><h1>In header</h1></header>":   "In header",
+		"<h10>Not h1</h10><h1>Actually h1</h1>": "Actually h1",
+	}
+
+	for html, expected := range tests {
+		actual := ssg.GetTitleFromHTML([]byte(html))
+		if string(actual) != expected {
+			t.Fatalf("unexpected title '%s' from '%s', expecting '%s'", actual, html, expected)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package ssg
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Page directives are lines in convertible files starting with the directive key.
+// Directive lines are removed from pages before conversion.
+const (
+	// DirectiveLayout selects a named layout from _layouts for the page,
+	// e.g. ":ssg-layout article"
+	DirectiveLayout = ":ssg-layout "
+
+	// DirectiveDate sets the page date, e.g. ":ssg-date 2023-03-24".
+	// See [ParseDate] for accepted formats.
+	DirectiveDate = ":ssg-date "
+)
+
+// DateFormats are the formats accepted by [ParseDate]
+var DateFormats = []string{
+	"2006-01-02",
+	"2006-01-02 15:04",
+	time.RFC3339,
+}
+
+// directives are page directives parsed from a convertible file
+type directives struct {
+	layout  string
+	date    string
+	tags    []string
+	draft   bool
+	aliases []string
+
+	// templates is the path templates are chosen for, if not the page itself,
+	// e.g. src for generated taxonomy pages. Not set by any directive.
+	templates string
+}
+
+// parseDirectives parses and removes known directives from data
+func parseDirectives(data []byte) (directives, []byte) {
+	var d directives
+	if layout, ok := GetDirective(data, DirectiveLayout); ok {
+		d.layout = string(layout)
+		data = RemoveDirective(data, DirectiveLayout)
+	}
+	if date, ok := GetDirective(data, DirectiveDate); ok {
+		d.date = string(date)
+		data = RemoveDirective(data, DirectiveDate)
+	}
+	if tags, ok := GetDirective(data, DirectiveTags); ok {
+		d.tags = parseList(string(tags))
+		data = RemoveDirective(data, DirectiveTags)
+	}
+	if aliases, ok := GetDirective(data, DirectiveAliases); ok {
+		d.aliases = parseList(string(aliases))
+		data = RemoveDirective(data, DirectiveAliases)
+	}
+	if value, ok := GetDirective(data, DirectiveDraft); ok {
+		if draft, ok := parseDraft(value); ok {
+			d.draft = draft
+			data = RemoveDirective(data, DirectiveDraft)
+		}
+	}
+	return d, data
+}
+
+// parseList parses comma-separated values, e.g. tags
+func parseList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// ParseDate parses s in one of [DateFormats]
+func ParseDate(s string) (time.Time, error) {
+	for i := range DateFormats {
+		t, err := time.Parse(DateFormats[i], s)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("bad date '%s', expecting one of %v", s, DateFormats)
+}
+
+// GetDirective returns the value of the first line starting with key,
+// with surrounding whitespace trimmed. A key without trailing space,
+// e.g. [DirectiveDraft], only matches the whole word.
+func GetDirective(data []byte, key string) ([]byte, bool) {
+	k := []byte(key)
+	s := bufio.NewScanner(bytes.NewBuffer(data))
+	for s.Scan() {
+		line := s.Bytes()
+		if !isDirective(line, k) {
+			continue
+		}
+		value := bytes.TrimSpace(bytes.TrimPrefix(line, k))
+		return append([]byte{}, value...), true
+	}
+	return nil, false
+}
+
+// RemoveDirective removes the first line starting with key from data,
+// including the line's newline.
+func RemoveDirective(data []byte, key string) []byte {
+	k := []byte(key)
+	start := 0
+	for start < len(data) {
+		end := bytes.IndexByte(data[start:], '\n')
+		if end == -1 {
+			end = len(data)
+		} else {
+			end += start + 1
+		}
+		if isDirective(data[start:end], k) {
+			removed := make([]byte, 0, len(data)-(end-start))
+			removed = append(removed, data[:start]...)
+			return append(removed, data[end:]...)
+		}
+		start = end
+	}
+	return data
+}
+
+// isDirective returns whether line is directive key
+func isDirective(line []byte, key []byte) bool {
+	if !bytes.HasPrefix(line, key) {
+		return false
+	}
+	if bytes.HasSuffix(key, []byte(" ")) {
+		return true
+	}
+	rest := line[len(key):]
+	return len(bytes.TrimSpace(rest)) == 0 || rest[0] == ' ' || rest[0] == '\t'
+}
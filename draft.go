@@ -0,0 +1,49 @@
+package ssg
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// DirectiveDraft marks a page as draft, e.g. ":ssg-draft" or ":ssg-draft true"
+const DirectiveDraft = ":ssg-draft"
+
+// parseDraft returns whether value of DirectiveDraft marks a page as draft
+func parseDraft(value []byte) (draft bool, ok bool) {
+	if len(value) == 0 {
+		return true, true
+	}
+	draft, err := strconv.ParseBool(string(value))
+	return draft, err == nil
+}
+
+// now returns the time against which publish dates are compared
+func (s *Ssg) now() time.Time {
+	if s.options.now.IsZero() {
+		return time.Now()
+	}
+	return s.options.now
+}
+
+// published reports whether page at path is published, i.e. it is not a draft
+// and its date is not in the future. All pages are published with [Drafts] option.
+func (s *Ssg) published(path string, page directives) (bool, error) {
+	if s.options.drafts {
+		return true, nil
+	}
+	if page.draft {
+		return false, nil
+	}
+	if c := s.configs.choose(path); c.Draft != nil && *c.Draft {
+		return false, nil
+	}
+	if page.date == "" {
+		return true, nil
+	}
+	date, err := ParseDate(page.date)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", path, err)
+	}
+	return !date.After(s.now()), nil
+}
@@ -0,0 +1,122 @@
+package ssg
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDrafts(t *testing.T) {
+	src := t.TempDir()
+
+	writeFiles(t, src, map[string]string{
+		"_header.html":       "",
+		"_footer.html":       "",
+		"blog/published.md":  ":ssg-tags go\n:ssg-date 2024-01-01\n# Published",
+		"blog/draft.md":      ":ssg-draft\n:ssg-tags go\n# Draft",
+		"blog/not-draft.md":  ":ssg-draft false\n# Not draft",
+		"blog/scheduled.md":  ":ssg-tags go\n:ssg-date 2024-06-01\n# Scheduled",
+		"wip/_ssg.json":      `{"draft": true}`,
+		"wip/page.md":        "# WIP",
+		"wip/style.css":      "body {}",
+		"wip/done/_ssg.json": `{"draft": false}`,
+		"wip/done/page.md":   "# Done",
+	})
+
+	// Generated index and tag pages, which have no source files
+	expectedGenerated := []string{"blog/index.html", "index.html", "tags/go/index.html", "tags/index.html", "wip/done/index.html", "wip/index.html"}
+
+	build := func(opts ...Option) []string {
+		dst := filepath.Join(t.TempDir(), "dst")
+		opts = append([]Option{AutoIndex(true), Tags("tags"), Now(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))}, opts...)
+		files, outputs, err := Build(src, dst, "TestDrafts", "https://example.com", nil, opts...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(files) != len(outputs)-len(expectedGenerated) {
+			t.Fatalf("unexpected number of files for .files: files=%v", files)
+		}
+
+		sitemap, err := Sitemap(dst, "https://example.com", time.Now(), outputs)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var rels []string
+		for i := range outputs {
+			o := &outputs[i]
+			rel, err := filepath.Rel(dst, o.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data := string(o.data)
+			if strings.Contains(data, DirectiveDraft) {
+				t.Fatalf("unexpected draft directive in '%s'", rel)
+			}
+			for _, page := range []string{"draft.html", "scheduled.html"} {
+				if strings.Contains(sitemap, "blog/"+page) {
+					continue
+				}
+				if strings.Contains(data, "blog/"+page) || strings.Contains(data, `href="`+page) {
+					t.Fatalf("unexpected listing of unpublished '%s' in '%s'", page, rel)
+				}
+			}
+			rels = append(rels, rel)
+		}
+		sort.Strings(rels)
+		return rels
+	}
+
+	expected := []string{
+		"blog/index.html",
+		"blog/not-draft.html",
+		"blog/published.html",
+		"index.html",
+		"tags/go/index.html",
+		"tags/index.html",
+		"wip/done/index.html",
+		"wip/done/page.html",
+		"wip/index.html",
+		"wip/style.css",
+	}
+	if actual := build(); strings.Join(actual, " ") != strings.Join(expected, " ") {
+		t.Fatalf("unexpected outputs without drafts:\nexpected=%v\nactual=%v", expected, actual)
+	}
+
+	expected = []string{
+		"blog/draft.html",
+		"blog/index.html",
+		"blog/not-draft.html",
+		"blog/published.html",
+		"blog/scheduled.html",
+		"index.html",
+		"tags/go/index.html",
+		"tags/index.html",
+		"wip/done/index.html",
+		"wip/done/page.html",
+		"wip/index.html",
+		"wip/page.html",
+		"wip/style.css",
+	}
+	if actual := build(Drafts(true)); strings.Join(actual, " ") != strings.Join(expected, " ") {
+		t.Fatalf("unexpected outputs with drafts:\nexpected=%v\nactual=%v", expected, actual)
+	}
+}
+
+func TestDraftDirective(t *testing.T) {
+	data := []byte(":ssg-drafts notes\n:ssg-draft\n# Page\n")
+	page, data := parseDirectives(data)
+	if !page.draft {
+		t.Fatal("unexpected non-draft page")
+	}
+	if string(data) != ":ssg-drafts notes\n# Page\n" {
+		t.Fatalf("unexpected data after parsing directives:\n%s", data)
+	}
+
+	page, _ = parseDirectives([]byte(":ssg-drafts 1\n# Page\n"))
+	if page.draft {
+		t.Fatal("unexpected draft page from :ssg-drafts")
+	}
+}
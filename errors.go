@@ -0,0 +1,89 @@
+package ssg
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Stage is the build stage in which a [BuildError] happened
+type Stage string
+
+const (
+	StageCollect      Stage = "collect"      // Collecting templates and configuration of a directory
+	StageRead         Stage = "read"         // Reading an input file
+	StagePipeline     Stage = "pipeline"     // Pipeline at Index
+	StageHook         Stage = "hook"         // Hook at Index
+	StageConvert      Stage = "convert"      // Converting to HTML
+	StageHookGenerate Stage = "hookGenerate" // HookGenerate at Index
+	StageCore         Stage = "core"         // Other errors in core, e.g. unknown layout
+	StageOutput       Stage = "output"       // Adding outputs, e.g. output collisions
+	StageWrite        Stage = "write"        // Writing an output
+)
+
+// BuildError is an error from building or writing a single source path
+type BuildError struct {
+	Path   string // Source path, empty for generated outputs, e.g. sitemap.xml
+	Stage  Stage
+	Index  int    // Index of pipeline or hook for StagePipeline, StageHook and StageHookGenerate
+	Target string // Output target for StageWrite
+	Err    error
+}
+
+// BuildErrors are all errors from a build with [ContinueOnError],
+// or from writing outputs with [WriteOut]
+type BuildErrors []*BuildError
+
+func (e *BuildError) Error() string {
+	switch e.Stage {
+	case StagePipeline, StageHook, StageHookGenerate:
+		return fmt.Sprintf("%s: %s[%d]: %s", e.Path, e.Stage, e.Index, e.Err.Error())
+	case StageWrite:
+		if e.Path == "" {
+			return fmt.Sprintf("%s '%s': %s", e.Stage, e.Target, e.Err.Error())
+		}
+		return fmt.Sprintf("%s: %s '%s': %s", e.Path, e.Stage, e.Target, e.Err.Error())
+	}
+	return fmt.Sprintf("%s: %s: %s", e.Path, e.Stage, e.Err.Error())
+}
+
+func (e *BuildError) Unwrap() error { return e.Err }
+
+func (e BuildErrors) Error() string {
+	lines := make([]string, len(e))
+	for i := range e {
+		lines[i] = e[i].Error()
+	}
+	return fmt.Sprintf("%d error(s):\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+func (e BuildErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i := range e {
+		errs[i] = e[i]
+	}
+	return errs
+}
+
+// stageError wraps err from building path in stage as [BuildError],
+// unless err already is a BuildError
+func stageError(path string, stage Stage, index int, err error) error {
+	var e *BuildError
+	if errors.As(err, &e) {
+		return err
+	}
+	return &BuildError{Path: path, Stage: stage, Index: index, Err: err}
+}
+
+// fail returns err, or records err and returns nil with [ContinueOnError]
+func (s *Ssg) fail(err error) error {
+	if err == nil || !s.options.continueOnError {
+		return err
+	}
+	var e *BuildError
+	if !errors.As(err, &e) {
+		e = &BuildError{Stage: StageCore, Err: err}
+	}
+	s.errs = append(s.errs, e)
+	return nil
+}
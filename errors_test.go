@@ -0,0 +1,313 @@
+package ssg
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestContinueOnError(t *testing.T) {
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{
+		"_header.html":  "",
+		"_footer.html":  "",
+		"ok.md":         "# OK",
+		"pipeline.md":   "# Pipeline",
+		"hook.md":       "# Hook",
+		"convert.txt":   "convert",
+		"layout.md":     ":ssg-layout missing\n# Layout",
+		"blog/post.md":  "# Post",
+		"blog/index.md": "# Blog",
+	})
+
+	errPipeline := errors.New("pipeline failed")
+	errHook := errors.New("hook failed")
+	errConvert := errors.New("convert failed")
+
+	opts := []Option{
+		WithPipelines(func(path string, data []byte, d fs.DirEntry) (string, []byte, fs.DirEntry, error) {
+			if filepath.Base(path) == "pipeline.md" {
+				return "", nil, nil, errPipeline
+			}
+			return path, data, d, nil
+		}),
+		WithHooks(func(path string, data []byte) ([]byte, error) {
+			if filepath.Base(path) == "hook.md" {
+				return nil, errHook
+			}
+			return data, nil
+		}),
+		WithConverter(".txt", ConverterFunc(func(string, []byte) ([]byte, Meta, error) {
+			return nil, Meta{}, errConvert
+		})),
+	}
+
+	dst := filepath.Join(t.TempDir(), "dst")
+	_, _, err := Build(src, dst, "TestContinueOnError", "https://example.com", nil, opts...)
+	var e *BuildError
+	if !errors.As(err, &e) {
+		t.Fatalf("unexpected error without ContinueOnError: %v", err)
+	}
+
+	_, outputs, err := Build(src, dst, "TestContinueOnError", "https://example.com", nil, append(opts, ContinueOnError(true))...)
+	var errs BuildErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("unexpected error with ContinueOnError: %v", err)
+	}
+
+	type expected struct {
+		stage Stage
+		index int
+		cause error
+	}
+	expecteds := map[string]expected{
+		"pipeline.md": {stage: StagePipeline, cause: errPipeline},
+		"hook.md":     {stage: StageHook, cause: errHook},
+		"convert.txt": {stage: StageConvert, cause: errConvert},
+		"layout.md":   {stage: StageCore, cause: ErrUnknownLayout},
+	}
+	if len(errs) != len(expecteds) {
+		t.Fatalf("unexpected number of errors: expected=%d, actual=%d\n%v", len(expecteds), len(errs), errs)
+	}
+	for _, e := range errs {
+		rel, err := filepath.Rel(src, e.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ex, ok := expecteds[rel]
+		if !ok {
+			t.Fatalf("unexpected error for '%s': %v", rel, e)
+		}
+		if e.Stage != ex.stage || e.Index != ex.index {
+			t.Fatalf("unexpected stage for '%s': expected=%s[%d], actual=%s[%d]", rel, ex.stage, ex.index, e.Stage, e.Index)
+		}
+		if !errors.Is(e, ex.cause) {
+			t.Fatalf("unexpected cause for '%s': %v", rel, e)
+		}
+		if !strings.Contains(errs.Error(), e.Error()) {
+			t.Fatalf("missing error for '%s' in summary:\n%s", rel, errs.Error())
+		}
+	}
+	if !errors.Is(err, errHook) {
+		t.Fatalf("errors.Is does not find hook error in %v", err)
+	}
+
+	built := make(map[string]bool)
+	for _, o := range outputs {
+		rel, err := filepath.Rel(dst, o.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		built[rel] = true
+	}
+	for _, rel := range []string{"ok.html", "blog/post.html", "blog/index.html"} {
+		if !built[rel] {
+			t.Fatalf("missing output '%s' with ContinueOnError", rel)
+		}
+	}
+}
+
+func TestWriteOutErrors(t *testing.T) {
+	dst := t.TempDir()
+	blocker := filepath.Join(dst, "blocker")
+	err := os.WriteFile(blocker, nil, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputs := []OutputFile{
+		Output(filepath.Join(dst, "ok.html"), "src/ok.md", []byte("ok"), 0o644),
+		Output(filepath.Join(blocker, "a.html"), "src/blocker/a.md", []byte("a"), 0o644),
+		Output(filepath.Join(blocker, "b.html"), "src/blocker/b.md", []byte("b"), 0o644),
+	}
+	err = WriteOutSlice(outputs, 2)
+	var errs BuildErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("unexpected number of errors: expected=2, actual=%d\n%v", len(errs), errs)
+	}
+	if !strings.Contains(err.Error(), filepath.Join(blocker, "a.html")) {
+		t.Fatalf("missing target in error: %v", err)
+	}
+	for _, e := range errs {
+		if e.Stage != StageWrite || !strings.HasPrefix(e.Path, "src/blocker/") || !strings.HasPrefix(e.Target, blocker) {
+			t.Fatalf("unexpected error: %v", e)
+		}
+	}
+}
+
+func TestWriteOutMoreErrorsThanWriters(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	files := map[string]string{"_header.html": "", "_footer.html": ""}
+	for i := 0; i < 8; i++ {
+		files[fmt.Sprintf("blocker/p%d.md", i)] = "# Page"
+	}
+	writeFiles(t, src, files)
+	writeFiles(t, dst, map[string]string{
+		".files":  "",
+		"blocker": "not a directory",
+	})
+
+	err := Generate(src, dst, "TestWriteOutMoreErrorsThanWriters", "https://example.com", Writers(2), ContinueOnError(true))
+	var errs BuildErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(errs) != 8 {
+		t.Fatalf("unexpected number of errors: expected=8, actual=%d\n%v", len(errs), errs)
+	}
+
+	outputs := make([]OutputFile, 8)
+	for i := range outputs {
+		outputs[i] = Output(filepath.Join(dst, "blocker", fmt.Sprintf("p%d.html", i)), "", nil, 0o644)
+	}
+	err = WriteOutSlice(outputs, 2)
+	if !errors.As(err, &errs) || len(errs) != len(outputs) {
+		t.Fatalf("unexpected error from WriteOutSlice: %v", err)
+	}
+}
+
+func TestNoPanics(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "dst")
+	writeFiles(t, src, map[string]string{"index.md": "# Index"})
+
+	tests := []struct {
+		name     string
+		src      string
+		dst      string
+		opts     []Option
+		expected error
+	}{
+		{name: "empty src", src: "", dst: dst, expected: ErrEmptySrc},
+		{name: "empty dst", src: src, dst: "", expected: ErrEmptyDst},
+		{name: "src is dst", src: src, dst: src + "/", expected: ErrSrcIsDst},
+		{name: "bad pipeline", src: src, dst: dst, opts: []Option{WithPipelines("not a pipeline")}, expected: ErrInvalidPipeline},
+		{
+			name:     "bad replacement",
+			src:      src,
+			dst:      dst,
+			opts:     []Option{WithReplacements(Replacements{"k": {Text: "text", Env: "SSG_TEST"}}, ReplaceUnknownError)},
+			expected: ErrBadReplacement,
+		},
+	}
+
+	for _, tc := range tests {
+		_, err := NewChecked(tc.src, tc.dst, "TestNoPanics", "https://example.com", tc.opts...)
+		if !errors.Is(err, tc.expected) {
+			t.Fatalf("[%s] unexpected error from NewChecked: %v", tc.name, err)
+		}
+		s := NewWithOptions(tc.src, tc.dst, "TestNoPanics", "https://example.com", tc.opts...)
+		if !errors.Is(s.Err(), tc.expected) {
+			t.Fatalf("[%s] unexpected error from Err: %v", tc.name, s.Err())
+		}
+		_, _, err = s.Build(nil)
+		if !errors.Is(err, tc.expected) {
+			t.Fatalf("[%s] unexpected error from Build: %v", tc.name, err)
+		}
+		err = Generate(tc.src, tc.dst, "TestNoPanics", "https://example.com", tc.opts...)
+		if !errors.Is(err, tc.expected) {
+			t.Fatalf("[%s] unexpected error from Generate: %v", tc.name, err)
+		}
+	}
+
+	checked, err := NewChecked(src, dst, "TestNoPanics", "https://example.com")
+	if err != nil || checked.Err() != nil {
+		t.Fatalf("unexpected error from NewChecked: %v", err)
+	}
+
+	// Errors from options applied with With are returned by Build
+	s := New(src, dst, "TestNoPanics", "https://example.com")
+	s.With(WithPipelines(42))
+	_, _, err = s.Build(nil)
+	if !errors.Is(err, ErrInvalidPipeline) {
+		t.Fatalf("unexpected error from Build: %v", err)
+	}
+
+	badIgnore := t.TempDir()
+	err = os.Mkdir(filepath.Join(badIgnore, MarkerSsgIgnore), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s = New(badIgnore, dst, "TestNoPanics", "https://example.com")
+	_, _, err = s.Build(nil)
+	if !errors.Is(err, ErrBadSsgIgnore) {
+		t.Fatalf("unexpected error for bad ssgignore: %v", err)
+	}
+
+	_, err = DotFiles(src, []string{filepath.Join(src, "index.md"), ""})
+	if !errors.Is(err, ErrEmptyFile) {
+		t.Fatalf("unexpected error for empty file: %v", err)
+	}
+}
+
+func TestGenerateMetadataOnError(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "dst")
+	writeFiles(t, src, map[string]string{
+		"_header.html": "",
+		"_footer.html": "",
+		"a/index.md":   "# A",
+		"b/index.md":   "# B",
+	})
+
+	err := Generate(src, dst, "TestGenerateMetadataOnError", "https://example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dst, name))
+		if err != nil {
+			t.Fatalf("missing %s: %v", name, err)
+		}
+		return string(data)
+	}
+	dotFiles, sitemap := read(".files"), read("sitemap.xml")
+
+	// Failed builds keep metadata of the good build
+	writeFiles(t, src, map[string]string{"b/index.md": ":ssg-layout missing\n# B"})
+	for _, opts := range [][]Option{nil, {ContinueOnError(true)}} {
+		err = Generate(src, dst, "TestGenerateMetadataOnError", "https://example.com", opts...)
+		if !errors.Is(err, ErrUnknownLayout) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		path := filepath.Join(src, "b", "index.md")
+		if strings.Count(err.Error(), path) != 1 {
+			t.Fatalf("unexpected error message: %v", err)
+		}
+		if read(".files") != dotFiles || read("sitemap.xml") != sitemap {
+			t.Fatal("unexpected metadata after failed build")
+		}
+	}
+
+	// Failed first builds leave dst recognized as generated by ssg
+	err = os.RemoveAll(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Generate(src, dst, "TestGenerateMetadataOnError", "https://example.com")
+	if !errors.Is(err, ErrUnknownLayout) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = os.Stat(filepath.Join(dst, "sitemap.xml"))
+	if !os.IsNotExist(err) {
+		t.Fatalf("unexpected sitemap.xml after failed build: %v", err)
+	}
+
+	writeFiles(t, src, map[string]string{"b/index.md": "# B"})
+	err = Generate(src, dst, "TestGenerateMetadataOnError", "https://example.com")
+	if err != nil {
+		t.Fatalf("unexpected error after fix: %v", err)
+	}
+	if read(".files") != dotFiles {
+		t.Fatalf("unexpected .files after fix:\n%s", read(".files"))
+	}
+}
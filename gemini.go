@@ -0,0 +1,212 @@
+package ssg
+
+import (
+	"bytes"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+const (
+	MarkerHeaderGemini = "_header.gmi"
+	MarkerFooterGemini = "_footer.gmi"
+)
+
+type gemtextLink struct {
+	dst  string
+	text string
+}
+
+// ToGemtext converts md (Markdown) into gemtext, parsing md with ext.
+//
+// Headings, list items, quotes and preformatted blocks are mapped to their
+// gemtext equivalents. Links and images in a block are moved to "=>" lines
+// following the block, with relative links to .md and .html files
+// pointing to .gmi files instead.
+func ToGemtext(md []byte, ext parser.Extensions) []byte {
+	root := markdown.Parse(md, parser.NewWithExtensions(ext))
+	buf := bytes.NewBuffer(nil)
+	for _, block := range root.GetChildren() {
+		text := gemtextBlock(block)
+		if len(text) == 0 {
+			continue
+		}
+		if buf.Len() != 0 {
+			buf.WriteByte('\n')
+		}
+		buf.Write(text)
+	}
+	return buf.Bytes()
+}
+
+// gemtextBlock renders a top-level Markdown block as gemtext lines
+func gemtextBlock(block ast.Node) []byte {
+	buf := bytes.NewBuffer(nil)
+	var links []gemtextLink
+
+	switch node := block.(type) {
+	case *ast.Heading:
+		level := min(node.Level, 3)
+		buf.WriteString(strings.Repeat("#", level) + " ")
+		buf.WriteString(gemtextInline(node, &links))
+		buf.WriteByte('\n')
+
+	case *ast.Paragraph:
+		// A paragraph with only a link becomes a single link line
+		if link := gemtextOnlyLink(node); link != nil {
+			writeGemtextLinks(buf, []gemtextLink{*link})
+			return buf.Bytes()
+		}
+		text := gemtextInline(node, &links)
+		if strings.TrimSpace(text) != "" {
+			buf.WriteString(text)
+			buf.WriteByte('\n')
+		}
+
+	case *ast.List:
+		ast.WalkFunc(node, func(n ast.Node, entering bool) ast.WalkStatus {
+			item, ok := n.(*ast.ListItem)
+			if !ok || !entering {
+				return ast.GoToNext
+			}
+			for _, child := range item.GetChildren() {
+				if _, ok := child.(*ast.List); ok {
+					continue
+				}
+				buf.WriteString("* ")
+				buf.WriteString(gemtextInline(child, &links))
+				buf.WriteByte('\n')
+			}
+			return ast.GoToNext
+		})
+
+	case *ast.BlockQuote:
+		for _, child := range node.GetChildren() {
+			text := gemtextInline(child, &links)
+			for _, line := range strings.Split(text, "\n") {
+				buf.WriteString("> " + line + "\n")
+			}
+		}
+
+	case *ast.CodeBlock:
+		buf.WriteString("```")
+		buf.Write(node.Info)
+		buf.WriteByte('\n')
+		buf.Write(node.Literal)
+		if !bytes.HasSuffix(node.Literal, []byte{'\n'}) {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString("```\n")
+
+	case *ast.Table:
+		buf.WriteString("```\n")
+		ast.WalkFunc(node, func(n ast.Node, entering bool) ast.WalkStatus {
+			row, ok := n.(*ast.TableRow)
+			if !ok || !entering {
+				return ast.GoToNext
+			}
+			cells := make([]string, 0, len(row.GetChildren()))
+			for _, cell := range row.GetChildren() {
+				cells = append(cells, gemtextInline(cell, &links))
+			}
+			buf.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+			return ast.SkipChildren
+		})
+		buf.WriteString("```\n")
+	}
+
+	writeGemtextLinks(buf, links)
+	return buf.Bytes()
+}
+
+// gemtextInline returns inline text of node, appending links found to links
+func gemtextInline(node ast.Node, links *[]gemtextLink) string {
+	var b strings.Builder
+	ast.WalkFunc(node, func(n ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		if link := gemtextLinkOf(n); link != nil {
+			*links = append(*links, *link)
+			b.WriteString(link.text)
+			return ast.SkipChildren
+		}
+
+		switch leaf := n.(type) {
+		case *ast.Text:
+			// Gemtext lines are paragraphs, so we unwrap soft line breaks
+			b.Write(bytes.ReplaceAll(leaf.Literal, []byte{'\n'}, []byte{' '}))
+		case *ast.Code:
+			b.Write(leaf.Literal)
+		case *ast.Softbreak:
+			b.WriteByte(' ')
+		case *ast.Hardbreak:
+			b.WriteByte('\n')
+		}
+		return ast.GoToNext
+	})
+	return b.String()
+}
+
+// gemtextOnlyLink returns the link if it is the only
+// non-whitespace child of paragraph
+func gemtextOnlyLink(paragraph *ast.Paragraph) *gemtextLink {
+	var link *gemtextLink
+	for _, child := range paragraph.GetChildren() {
+		if text, ok := child.(*ast.Text); ok && len(bytes.TrimSpace(text.Literal)) == 0 {
+			continue
+		}
+		if link != nil {
+			return nil
+		}
+		link = gemtextLinkOf(child)
+		if link == nil {
+			return nil
+		}
+	}
+	return link
+}
+
+func gemtextLinkOf(node ast.Node) *gemtextLink {
+	switch n := node.(type) {
+	case *ast.Link:
+		return &gemtextLink{
+			dst:  gemtextDestination(string(n.Destination)),
+			text: textContent(n),
+		}
+	case *ast.Image:
+		return &gemtextLink{
+			dst:  string(n.Destination),
+			text: textContent(n),
+		}
+	}
+	return nil
+}
+
+// gemtextDestination maps relative links to Markdown
+// or HTML pages to their gemtext counterparts
+func gemtextDestination(dst string) string {
+	u, err := url.Parse(dst)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return dst
+	}
+	switch filepath.Ext(u.Path) {
+	case ".md", ".html":
+		u.Path = strings.TrimSuffix(u.Path, filepath.Ext(u.Path)) + ".gmi"
+	}
+	return u.String()
+}
+
+func writeGemtextLinks(buf *bytes.Buffer, links []gemtextLink) {
+	for _, link := range links {
+		buf.WriteString("=> " + link.dst)
+		if link.text != "" && link.text != link.dst {
+			buf.WriteString(" " + link.text)
+		}
+		buf.WriteByte('\n')
+	}
+}
@@ -0,0 +1,199 @@
+package ssg_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soyart/ssg-go"
+)
+
+func TestToGemtext(t *testing.T) {
+	type testCase struct {
+		md      string
+		gemtext string
+	}
+
+	tests := []testCase{
+		{
+			md:      "",
+			gemtext: "",
+		},
+		{
+			md:      "# H1\n\n## H2\n\n#### H4",
+			gemtext: "# H1\n\n## H2\n\n### H4\n",
+		},
+		{
+			md: "Some *para* with [a link](https://example.com)\nand `code`.",
+			gemtext: `Some para with a link and code.
+=> https://example.com a link
+`,
+		},
+		{
+			md:      "[Foo page](foo.html)",
+			gemtext: "=> foo.gmi Foo page\n",
+		},
+		{
+			md:      "![An image](/img/foo.png)",
+			gemtext: "=> /img/foo.png An image\n",
+		},
+		{
+			md: `- one
+- [two](../two.md#sec)
+  - nested`,
+			gemtext: `* one
+* two
+* nested
+=> ../two.gmi#sec two
+`,
+		},
+		{
+			md:      "> quoted\n> text",
+			gemtext: "> quoted text\n",
+		},
+		{
+			md:      "```sh\necho foo\n```",
+			gemtext: "```sh\necho foo\n```\n",
+		},
+		{
+			md:      "| a | b |\n|---|---|\n| 1 | 2 |",
+			gemtext: "```\n| a | b |\n| 1 | 2 |\n```\n",
+		},
+	}
+
+	for i := range tests {
+		tc := &tests[i]
+		actual := ssg.ToGemtext([]byte(tc.md), ssg.SsgExtensions)
+		if string(actual) != tc.gemtext {
+			t.Logf("expected:\n%s", tc.gemtext)
+			t.Logf("actual:\n%s", actual)
+			t.Fatalf("unexpected gemtext from case %d", i+1)
+		}
+	}
+}
+
+func TestGemini(t *testing.T) {
+	src := t.TempDir()
+	tmp := t.TempDir()
+	dst := filepath.Join(tmp, "dst")
+	dstGemini := filepath.Join(tmp, "dst-gemini")
+
+	files := map[string]string{
+		"_header.gmi":       "=> / Home\n\n",
+		"index.md":          "# Home page\n\nWelcome",
+		"blog/_footer.gmi":  "\n=> /blog/ Blog index\n",
+		"blog/_header.html": "<title>{{from-tag}}</title>\n",
+		"blog/post.md":      ":ssg-title Post title\n\n# Post\n\nSome post",
+		"blog/style.css":    "body {}",
+	}
+	for name, content := range files {
+		path := filepath.Join(src, name)
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, outputs, err := ssg.Build(src, dst, "TestGemini", "https://example.com", nil, ssg.Gemini(dstGemini))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expecteds := map[string]string{
+		filepath.Join(dst, "index.html"):           "",
+		filepath.Join(dst, "blog/post.html"):       "",
+		filepath.Join(dst, "blog/style.css"):       "body {}",
+		filepath.Join(dstGemini, "index.gmi"):      "=> / Home\n\n# Home page\n\nWelcome\n",
+		filepath.Join(dstGemini, "blog/post.gmi"):  "=> / Home\n\n# Post\n\nSome post\n\n=> /blog/ Blog index\n",
+		filepath.Join(dstGemini, "blog/style.css"): "body {}",
+	}
+
+	if len(outputs) != len(expecteds) {
+		t.Fatalf("unexpected number of outputs: expected=%d, actual=%d", len(expecteds), len(outputs))
+	}
+	for i := range outputs {
+		o := &outputs[i]
+		expected, ok := expecteds[o.Target()]
+		if !ok {
+			t.Fatalf("unexpected output '%s'", o.Target())
+		}
+		if strings.HasPrefix(o.Target(), dst+string(filepath.Separator)) {
+			continue
+		}
+		if string(o.Data()) != expected {
+			t.Logf("expected:\n%s", expected)
+			t.Logf("actual:\n%s", o.Data())
+			t.Fatalf("unexpected data for '%s'", o.Target())
+		}
+	}
+
+	sitemap, err := ssg.Sitemap(dst, "https://example.com", time.Now(), outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sitemap, ".gmi") || strings.Contains(sitemap, "..") {
+		t.Fatalf("unexpected gemtext outputs in sitemap:\n%s", sitemap)
+	}
+}
+
+func TestGeminiDst(t *testing.T) {
+	src := t.TempDir()
+	tmp := t.TempDir()
+	dst := filepath.Join(tmp, "dst")
+	err := os.WriteFile(filepath.Join(src, "index.md"), []byte("# Home"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Empty gemini dst disables gemtext output
+	_, outputs, err := ssg.Build(src, dst, "TestGeminiDst", "https://example.com", nil, ssg.Gemini(""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(outputs) != 1 || outputs[0].Target() != filepath.Join(dst, "index.html") {
+		t.Fatalf("unexpected outputs with empty gemini dst: %+v", outputs)
+	}
+
+	tests := map[string]error{
+		filepath.Join(src, "gemini"): ssg.ErrDstInSrc,
+		dst:                          ssg.ErrSrcIsDst,
+		filepath.Join(dst, "gemini"): ssg.ErrDstInSrc,
+		tmp:                          ssg.ErrSrcInDst,
+	}
+	for gemini, expected := range tests {
+		_, _, err := ssg.Build(src, dst, "TestGeminiDst", "https://example.com", nil, ssg.Gemini(gemini))
+		if !errors.Is(err, expected) {
+			t.Fatalf("unexpected error for gemini dst '%s': %v", gemini, err)
+		}
+	}
+
+	// Gemini dst is checked for writes like dst
+	foreign := filepath.Join(tmp, "foreign")
+	err = os.MkdirAll(foreign, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(foreign, "keep.txt"), nil, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ssg.Generate(src, dst, "TestGeminiDst", "https://example.com", ssg.Gemini(foreign))
+	if !errors.Is(err, ssg.ErrForeignDst) {
+		t.Fatalf("unexpected error for foreign gemini dst: %v", err)
+	}
+
+	gemini := filepath.Join(tmp, "gemini")
+	for i := 0; i < 2; i++ {
+		err = ssg.Generate(src, dst, "TestGeminiDst", "https://example.com", ssg.Gemini(gemini))
+		if err != nil {
+			t.Fatalf("unexpected error from generate %d: %v", i+1, err)
+		}
+	}
+}
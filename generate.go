@@ -4,16 +4,30 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"sync"
 )
 
 func generate(s *Ssg) error {
 	const bufferMultiplier = 2
+	if s.errInit != nil {
+		return s.errInit
+	}
+	err := s.preflight(true)
+	if err != nil {
+		return err
+	}
 	stat, err := os.Stat(s.Src)
 	if err != nil {
 		return fmt.Errorf("failed to stat src '%s': %w", s.Src, err)
 	}
+	// Metadata is only written after successful builds, so dsts are claimed
+	// beforehand to be recognized as generated by ssg even if this build fails
+	for _, dst := range s.dsts() {
+		err = claimDst(dst)
+		if err != nil {
+			return err
+		}
+	}
 
 	stream := make(chan OutputFile, s.options.writers*bufferMultiplier)
 	outputs := NewOutputsStreaming(stream)
@@ -21,15 +35,14 @@ func generate(s *Ssg) error {
 	var wg sync.WaitGroup
 	wg.Add(2)
 	var errBuild error
-	var files []string
 	go func() {
 		defer func() {
 			close(stream)
 			wg.Done()
 		}()
 
-		var err error
-		files, _, err = s.Build(outputs)
+		// Preflight is already done with writes checked
+		_, _, err := s.build(outputs)
 		if err != nil {
 			errBuild = err
 		}
@@ -49,16 +62,28 @@ func generate(s *Ssg) error {
 
 	wg.Wait()
 
-	if errBuild != nil && errWrites != nil {
-		return fmt.Errorf("streaming_build_error='%w', streaming_write_error='%s'", errBuild, errWrites)
+	// Failed builds keep metadata of the previous build,
+	// which still lists outputs not overwritten by this build
+	var errMetadata error
+	if errBuild == nil && errWrites == nil {
+		errMetadata = GenerateMetadata(s.Src, s.Dst, s.URL, s.result.files, written, stat.ModTime())
 	}
-	if errBuild != nil {
-		return fmt.Errorf("streaming_build_error: %w", errBuild)
+
+	// Summarize all failures with ContinueOnError
+	var errsBuild, errsWrites BuildErrors
+	switch {
+	case errors.As(errBuild, &errsBuild) && (errWrites == nil || errors.As(errWrites, &errsWrites)):
+		err = append(errsBuild, errsWrites...)
+	case errBuild != nil && errWrites != nil:
+		err = fmt.Errorf("streaming_build_error='%w', streaming_write_error='%w'", errBuild, errWrites)
+	case errBuild != nil:
+		err = fmt.Errorf("streaming_build_error: %w", errBuild)
+	case errWrites != nil:
+		err = fmt.Errorf("streaming_write_error: %w", errWrites)
 	}
-	if errWrites != nil {
-		return fmt.Errorf("streaming_write_error: %w", errWrites)
+	if errMetadata != nil {
+		return errors.Join(err, errMetadata)
 	}
-	err = GenerateMetadata(s.Src, s.Dst, s.URL, files, written, stat.ModTime())
 	if err != nil {
 		return err
 	}
@@ -68,65 +93,73 @@ func generate(s *Ssg) error {
 
 // WriteOut blocks and concurrently writes outputs from stream until stream is closed.
 // It returns metadata for all outputs written, without the data.
+//
+// Outputs with the same target are written in stream order,
+// so the output received last wins.
 func WriteOut(stream <-chan OutputFile, concurrent int) ([]OutputFile, error) {
 	if concurrent == 0 {
 		concurrent = 1
 	}
 
 	written := make([]OutputFile, 0) // No data, only metadata
+	indexes := make(map[string]int)  // Index of written, keyed by target
 	wg := new(sync.WaitGroup)
-	errs := make(chan errorWrite)
 	guard := make(chan struct{}, concurrent)
-	mut := new(sync.Mutex)
+	mut := new(sync.Mutex) // Guards written, indexes and wErrs
+	var wErrs BuildErrors
+
+	// Last write to each target, closed when the write is done
+	last := make(map[string]chan struct{})
 
 	for w := range stream {
+		prev := last[w.target]
+		done := make(chan struct{})
+		last[w.target] = done
+
 		guard <- struct{}{}
 		wg.Add(1)
 
 		go func(w *OutputFile, wg *sync.WaitGroup) {
 			defer func() {
+				close(done)
 				<-guard
 				wg.Done()
 			}()
 
-			err := os.MkdirAll(filepath.Dir(w.target), os.ModePerm)
-			if err != nil {
-				errs <- errorWrite{
-					err:        err,
-					target:     w.target,
-					originator: w.originator,
-				}
-				return
-			}
-			err = os.WriteFile(w.target, w.data, w.Perm())
-			if err != nil {
-				errs <- errorWrite{
-					err:        err,
-					target:     w.target,
-					originator: w.originator,
-				}
-				return
+			if prev != nil {
+				<-prev
 			}
 
+			err := w.write()
+
 			mut.Lock()
 			defer mut.Unlock()
 
-			written = append(written, Output(w.target, w.originator, nil, w.perm))
+			if err != nil {
+				wErrs = append(wErrs, &BuildError{
+					Path:   w.originator,
+					Stage:  StageWrite,
+					Target: w.target,
+					Err:    err,
+				})
+				return
+			}
+
+			metadata := *w
+			metadata.data = nil
+			if i, ok := indexes[w.target]; ok {
+				written[i] = metadata
+			} else {
+				indexes[w.target] = len(written)
+				written = append(written, metadata)
+			}
 			Fprintln(os.Stdout, w.target)
 		}(&w, wg)
 	}
 
-	go func() {
-		wg.Wait()
-		close(errs)
-	}()
-
-	var wErrs []error
-	for err := range errs { // Blocks here until errs is closed
-		wErrs = append(wErrs, err)
-	}
+	wg.Wait()
 	if len(wErrs) > 0 {
-		return nil, errors.Join(wErrs...)
+		return nil, wErrs
 	}
 
 	return written, nil
@@ -0,0 +1,176 @@
+package ssg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DirectiveInclude splices another file into a page or template,
+// e.g. ":ssg-include _signup.md" or ":ssg-include /_partials/disclaimer.html".
+//
+// Included paths are relative to the including file's directory,
+// or to the site root if prefixed with "/".
+const DirectiveInclude = ":ssg-include "
+
+var (
+	ErrIncludeCycle      = errors.New("include cycle")
+	ErrIncludeOutsideSrc = errors.New("include outside src")
+)
+
+// Include expands include directives in data read from path.
+// Included files are expanded recursively, and an include cycle is an error.
+//
+// Markdown included into a non-Markdown file, e.g. a header template,
+// is converted to HTML with the default Markdown dialect.
+func Include(root, path string, data []byte) ([]byte, error) {
+	return include(root, path, data, []string{path})
+}
+
+func include(root, path string, data []byte, stack []string) ([]byte, error) {
+	k := []byte(DirectiveInclude)
+	if !bytes.Contains(data, k) {
+		return data, nil
+	}
+
+	buf := bytes.NewBuffer(nil)
+	for len(data) > 0 {
+		var line []byte
+		end := bytes.IndexByte(data, '\n')
+		if end == -1 {
+			line, data = data, nil
+		} else {
+			line, data = data[:end+1], data[end+1:]
+		}
+		if !bytes.HasPrefix(line, k) {
+			buf.Write(line)
+			continue
+		}
+
+		name := string(bytes.TrimSpace(bytes.TrimPrefix(line, k)))
+		target, err := includeTarget(root, path, name)
+		if err != nil {
+			return nil, err
+		}
+
+		chain := append(stack[:len(stack):len(stack)], target)
+		for i := range stack {
+			if stack[i] == target {
+				return nil, fmt.Errorf("%w: %s", ErrIncludeCycle, strings.Join(chain, " -> "))
+			}
+		}
+
+		included, err := ReadFile(target)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to include '%s': %w", path, name, err)
+		}
+		included, err = include(root, target, included, chain)
+		if err != nil {
+			return nil, err
+		}
+		if filepath.Ext(target) == ".md" && filepath.Ext(path) != ".md" {
+			included = ToHTML(included)
+		}
+
+		buf.Write(included)
+		if end != -1 && len(included) > 0 && included[len(included)-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// includeTarget resolves included name in file at path.
+// Included files must be inside root.
+func includeTarget(root, path, name string) (string, error) {
+	target := filepath.Join(filepath.Dir(path), name)
+	if strings.HasPrefix(name, "/") {
+		target = filepath.Join(root, name)
+	}
+	if !isWithin(root, target) {
+		return "", fmt.Errorf("%w: %s: '%s' is outside '%s'", ErrIncludeOutsideSrc, path, name, root)
+	}
+	return target, nil
+}
+
+// includeTargets returns all files included by data read from path
+func includeTargets(root, path string, data []byte) []string {
+	k := []byte(DirectiveInclude)
+	if !bytes.Contains(data, k) {
+		return nil
+	}
+	var targets []string
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if !bytes.HasPrefix(line, k) {
+			continue
+		}
+		name := string(bytes.TrimSpace(bytes.TrimPrefix(line, k)))
+		target, err := includeTarget(root, path, name)
+		if err != nil {
+			continue // Reported when the file is built
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// collectIncludes records files included by pages and templates in src.
+// Included files are excluded from standalone output, like _header.html.
+func (s *Ssg) collectIncludes() error {
+	s.included = make(Set)
+	return filepath.WalkDir(s.Src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != s.Src && (strings.HasPrefix(d.Name(), ".") || s.skipDsts.Contains(path)) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !s.includes(path) {
+			return nil
+		}
+		return s.collectIncluded(path)
+	})
+}
+
+// collectIncluded reads file at path and records its includes recursively
+func (s *Ssg) collectIncluded(path string) error {
+	data, err := ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Reported when the file is built
+		}
+		return err
+	}
+	for _, target := range includeTargets(s.Src, path, data) {
+		if s.included.Insert(target) {
+			continue
+		}
+		err := s.collectIncluded(target)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// includes returns whether includes are expanded in file at path,
+// i.e. path is an HTML file or has a converter
+func (s *Ssg) includes(path string) bool {
+	if filepath.Ext(path) == ".html" {
+		return true
+	}
+	for ext := range s.options.converters {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return filepath.Ext(path) == ".md"
+}
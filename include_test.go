@@ -0,0 +1,128 @@
+package ssg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInclude(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	files := map[string]string{
+		"_header.html":              ":ssg-include /_partials/_nav.md\n",
+		"_footer.html":              "<!-- FOOTER -->\n",
+		"_partials/_nav.md":         "[Home](/)",
+		"_partials/_signup.html":    "<form>signup</form>\n",
+		"blog/_disclaimer.md":       "*Disclaimer*\n\n:ssg-include /_partials/_signup.html",
+		"blog/post.md":              "# Post\n\n:ssg-include _disclaimer.md\n\nBye",
+		"blog/table.frag.html":      "<h1>Table</h1>\n:ssg-include _disclaimer.md\n",
+		"_partials/not-partial.txt": "copied",
+		"_about.md":                 "# About",
+		"_notes.html":               "<p>notes</p>",
+	}
+	writeFiles(t, src, files)
+
+	s := New(src, dst, "TestInclude", "https://example.com")
+	s.With(Caching(true))
+	_, outputs, err := s.Build(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	nav := "<p><a href=\"/\">Home</a></p>\n"
+	expecteds := map[string]string{
+		"blog/post.html":            nav + "<h1 id=\"post\">Post</h1>\n\n<p><em>Disclaimer</em></p>\n\n<form>signup</form>\n\n<p>Bye</p>\n<!-- FOOTER -->\n",
+		"blog/table.html":           nav + "<h1>Table</h1>\n<p><em>Disclaimer</em></p>\n\n<form>signup</form>\n<!-- FOOTER -->\n",
+		"_partials/not-partial.txt": "copied",
+		"_about.html":               nav + "<h1 id=\"about\">About</h1>\n<!-- FOOTER -->\n",
+		"_notes.html":               "<p>notes</p>",
+	}
+
+	if len(outputs) != len(expecteds) {
+		for i := range outputs {
+			t.Logf("output: %s", outputs[i].target)
+		}
+		t.Fatalf("unexpected number of outputs: expected=%d, actual=%d", len(expecteds), len(outputs))
+	}
+	for i := range outputs {
+		o := &outputs[i]
+		rel, err := filepath.Rel(dst, o.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, ok := expecteds[rel]
+		if !ok {
+			t.Fatalf("unexpected output '%s'", rel)
+		}
+		if string(o.data) != expected {
+			t.Logf("expected:\n%s", expected)
+			t.Logf("actual:\n%s", o.data)
+			t.Fatalf("unexpected output data for '%s'", rel)
+		}
+	}
+
+	writeFiles(t, src, map[string]string{
+		"blog/_disclaimer.md": "*Disclaimer*\n:ssg-include _more.md",
+		"blog/_more.md":       ":ssg-include _disclaimer.md",
+	})
+	_, _, err = Build(src, dst, "TestInclude", "https://example.com", nil)
+	if !errors.Is(err, ErrIncludeCycle) {
+		t.Fatalf("unexpected error for include cycle: %v", err)
+	}
+
+	writeFiles(t, src, map[string]string{
+		"blog/_disclaimer.md": ":ssg-include _missing.md",
+	})
+	_, _, err = Build(src, dst, "TestInclude", "https://example.com", nil)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("unexpected error for missing include: %v", err)
+	}
+
+	for _, name := range []string{"../../outside.md", "/../../etc/passwd"} {
+		writeFiles(t, src, map[string]string{
+			"blog/_disclaimer.md": ":ssg-include " + name,
+		})
+		_, _, err = Build(src, dst, "TestInclude", "https://example.com", nil)
+		if !errors.Is(err, ErrIncludeOutsideSrc) {
+			t.Fatalf("unexpected error for include '%s': %v", name, err)
+		}
+	}
+}
+
+func TestIncludeReplacements(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "dst")
+	writeFiles(t, src, map[string]string{
+		"_header.html": "",
+		"_footer.html": "",
+		"_signup.md":   "Sign up at ${{ email }}",
+		"index.md":     ":ssg-include _signup.md",
+	})
+
+	_, outputs, err := Build(src, dst, "TestIncludeReplacements", "https://example.com", nil,
+		WithReplacements(Replacements{"email": {Text: "me@example.com"}}, ReplaceUnknownError),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(outputs) != 1 || string(outputs[0].data) != "<p>Sign up at me@example.com</p>\n" {
+		t.Fatalf("unexpected outputs: %+v", outputs)
+	}
+}
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
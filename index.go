@@ -0,0 +1,355 @@
+package ssg
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IndexSort is the order of pages in generated index pages
+type IndexSort uint8
+
+const (
+	IndexSortName  IndexSort = iota // By filename, ascending
+	IndexSortTitle                  // By page title, ascending
+	IndexSortDate                   // By :ssg-date, newest first
+)
+
+// indexEntry is a child page or subdirectory listed in a generated index
+type indexEntry struct {
+	name  string
+	href  string
+	title string
+	date  time.Time
+	dir   bool
+}
+
+func (i *IndexSort) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "name":
+		*i = IndexSortName
+	case "title":
+		*i = IndexSortTitle
+	case "date":
+		*i = IndexSortDate
+	default:
+		return fmt.Errorf("unknown index sort '%s', expecting name, title or date", text)
+	}
+	return nil
+}
+
+// autoIndex generates index.html for dir if index generation is enabled
+// and dir has no page whose output is index.html.
+//
+// The index lists subdirectories with index.html then child pages,
+// and is rendered through the directory's cascading templates.
+// Subdirectories must be indexed before dir.
+func (s *Ssg) autoIndex(dir string) ([]OutputFile, error) {
+	c := s.configs.choose(dir)
+	enabled := s.options.autoIndex
+	if c.Index != nil {
+		enabled = *c.Index
+	}
+	if !enabled || s.ssgignores(dir) {
+		return nil, nil
+	}
+	rel, err := filepath.Rel(s.Src, dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range strings.Split(rel, string(filepath.Separator)) {
+		if strings.HasPrefix(name, ".") && name != "." {
+			return nil, nil
+		}
+	}
+
+	children, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var dirs, pages []indexEntry
+	for i := range children {
+		child := children[i]
+		base := child.Name()
+		path := filepath.Join(dir, base)
+
+		isDir := child.IsDir()
+		if isSymlink(child) {
+			stat, err := os.Stat(path)
+			if err != nil || s.options.symlinks == SymlinkIgnore {
+				continue
+			}
+			isDir = stat.IsDir()
+
+			// Copied links to files are listed as is, without conversion
+			if s.options.symlinks == SymlinkCopy && !isDir {
+				if filepath.Ext(base) != ".html" || s.isTemplate(path) {
+					continue
+				}
+				if base == "index.html" {
+					return nil, nil
+				}
+				data, err := ReadFile(path)
+				if err != nil {
+					return nil, err
+				}
+				entry := indexEntry{name: base, href: base, title: string(GetTitleFromHTML(data))}
+				if entry.title == "" {
+					entry.title = base
+				}
+				pages = append(pages, entry)
+				continue
+			}
+		}
+
+		if isDir {
+			if strings.HasPrefix(base, ".") || s.ssgignores(path) || path == filepath.Join(s.Src, MarkerLayouts) || s.skipDsts.Contains(path) {
+				continue
+			}
+			// Subdirectories without index.html would 404
+			target, err := mirrorPath(s.Src, s.Dst, path)
+			if err != nil {
+				return nil, err
+			}
+			if !s.indexed.Contains(target) && !s.copiedIndex(child, path) {
+				continue
+			}
+			dirs = append(dirs, indexEntry{
+				name:  base,
+				href:  base + "/",
+				title: base + "/",
+				dir:   true,
+			})
+			continue
+		}
+
+		ignore, err := shouldIgnore(s.ssgignores, path, base, child)
+		if err != nil {
+			return nil, err
+		}
+		if ignore || s.isTemplate(path) {
+			continue
+		}
+
+		entry, ok, err := s.indexEntry(path)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if entry.href == "index.html" {
+			return nil, nil
+		}
+		pages = append(pages, entry)
+	}
+
+	if len(dirs) == 0 && len(pages) == 0 {
+		return nil, nil
+	}
+
+	var by IndexSort
+	if c.IndexSort != nil {
+		by = *c.IndexSort
+	}
+	sortIndex(pages, by)
+
+	heading := filepath.Base(dir)
+	if dir == s.Src {
+		heading = string(s.title(dir))
+	}
+
+	entries := append(dirs, pages...)
+	size := len(entries)
+	if c.IndexPageSize != nil && *c.IndexPageSize > 0 {
+		size = *c.IndexPageSize
+	}
+	total := (len(entries) + size - 1) / size
+
+	outputs := make([]OutputFile, 0, total)
+	for n := 1; n <= total; n++ {
+		from, to := (n-1)*size, n*size
+		if to > len(entries) {
+			to = len(entries)
+		}
+
+		// Generated index is rendered as if it was dir/index.frag.html,
+		// or dir/page/n/index.frag.html for subsequent pages
+		path := filepath.Join(dir, "index"+ExtFragment)
+		if n > 1 {
+			path = filepath.Join(dir, "page", strconv.Itoa(n), "index"+ExtFragment)
+		}
+		data := renderIndex(heading, entries[from:to], n, total)
+		output, err := s.coreHTML(path, ExtFragment, Fragment{}, data, directives{}, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate index for %s: %w", dir, err)
+		}
+		output.noSitemap = !s.sitemap(path)
+		outputs = append(outputs, output)
+	}
+
+	return outputs, nil
+}
+
+// copiedIndex reports whether child at path is a copied symlink
+// to a directory with index.html
+func (s *Ssg) copiedIndex(child os.DirEntry, path string) bool {
+	if !isSymlink(child) || s.options.symlinks != SymlinkCopy {
+		return false
+	}
+	stat, err := os.Stat(filepath.Join(path, "index.html"))
+	return err == nil && !stat.IsDir()
+}
+
+// renderIndex renders page n of total pages of an index listing entries.
+// Pages after the first are at page/n/ relative to the first page.
+func renderIndex(heading string, entries []indexEntry, n int, total int) []byte {
+	prefix := ""
+	if n > 1 {
+		prefix = "../../"
+		heading = fmt.Sprintf("%s - page %d", heading, n)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	Fprintf(buf, "<h1>%s</h1>\n<ul class=\"ssg-index\">\n", html.EscapeString(heading))
+	for _, entry := range entries {
+		Fprint(buf, "<li>")
+		if !entry.date.IsZero() {
+			date := entry.date.Format(time.DateOnly)
+			Fprintf(buf, "<time datetime=\"%s\">%s</time> ", date, date)
+		}
+		Fprintf(buf, "<a href=\"%s\">%s</a></li>\n", html.EscapeString(prefix+entry.href), html.EscapeString(entry.title))
+	}
+	Fprint(buf, "</ul>\n")
+
+	if total <= 1 {
+		return buf.Bytes()
+	}
+
+	Fprint(buf, "<nav class=\"ssg-pagination\">\n")
+	switch {
+	case n == 2:
+		Fprint(buf, "<a rel=\"prev\" href=\"../../\">Previous</a>\n")
+	case n > 2:
+		Fprintf(buf, "<a rel=\"prev\" href=\"../%d/\">Previous</a>\n", n-1)
+	}
+	switch {
+	case n == 1:
+		Fprint(buf, "<a rel=\"next\" href=\"page/2/\">Next</a>\n")
+	case n < total:
+		Fprintf(buf, "<a rel=\"next\" href=\"../%d/\">Next</a>\n", n+1)
+	}
+	Fprint(buf, "</nav>\n")
+
+	return buf.Bytes()
+}
+
+// indexEntry returns index entry for page at path.
+// ok is false if path does not produce an HTML page.
+func (s *Ssg) indexEntry(path string) (indexEntry, bool, error) {
+	base := filepath.Base(path)
+	ext, converter := s.options.converter(path, s.markdown(path))
+
+	switch {
+	case converter == nil && filepath.Ext(base) != ".html":
+		return indexEntry{}, false, nil
+
+	// Preferred HTML is listed instead
+	case converter != nil && s.preferred.Contains(ChangeExt(path, ext, ".html")):
+		return indexEntry{}, false, nil
+	}
+
+	data, err := ReadFile(path)
+	if err != nil {
+		return indexEntry{}, false, err
+	}
+
+	entry := indexEntry{name: base, href: base}
+	if converter == nil {
+		entry.title = string(GetTitleFromHTML(data))
+	} else {
+		var page directives
+		page, data = parseDirectives(data)
+		published, err := s.published(path, page)
+		if err != nil {
+			return indexEntry{}, false, err
+		}
+		if !published {
+			return indexEntry{}, false, nil
+		}
+		entry.title = string(GetTitleFromTag(data))
+		if entry.title == "" {
+			_, meta, err := converter.Convert(path, data)
+			if err != nil {
+				return indexEntry{}, false, fmt.Errorf("converter error when indexing %s: %w", path, err)
+			}
+			entry.title = string(meta.Title)
+		}
+		entry.href, err = s.pageHref(path, ext, []byte(entry.title), page)
+		if err != nil {
+			return indexEntry{}, false, err
+		}
+		if page.date != "" {
+			entry.date, err = ParseDate(page.date)
+			if err != nil {
+				return indexEntry{}, false, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+	}
+
+	if entry.title == "" {
+		entry.title = entry.href
+	}
+	return entry, true, nil
+}
+
+// pageHref returns URL of page at path relative to its directory,
+// e.g. foo.html, or foo/ with [PrettyURLs]
+func (s *Ssg) pageHref(path, ext string, title []byte, page directives) (string, error) {
+	target, _, err := s.pageTarget(path, ext, title, page)
+	if err != nil {
+		return "", err
+	}
+	dir, err := mirrorPath(s.Src, s.Dst, filepath.Dir(path))
+	if err != nil {
+		return "", err
+	}
+	href, err := filepath.Rel(dir, target)
+	if err != nil {
+		return "", err
+	}
+	href = filepath.ToSlash(href)
+	if href != "index.html" && strings.HasSuffix(href, "/index.html") {
+		href = strings.TrimSuffix(href, "index.html")
+	}
+	return href, nil
+}
+
+// sortIndex sorts entries by key, with ties broken by name.
+// Undated entries are sorted after dated entries.
+func sortIndex(entries []indexEntry, by IndexSort) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := &entries[i], &entries[j]
+		switch by {
+		case IndexSortTitle:
+			if a.title != b.title {
+				return a.title < b.title
+			}
+		case IndexSortDate:
+			if !a.date.Equal(b.date) {
+				if a.date.IsZero() || b.date.IsZero() {
+					return b.date.IsZero()
+				}
+				return a.date.After(b.date)
+			}
+		}
+		return a.name < b.name
+	})
+}
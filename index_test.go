@@ -0,0 +1,224 @@
+package ssg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAutoIndex(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	writeFiles(t, src, map[string]string{
+		"_header.html":              "<title>{{from-h1}}</title>\n",
+		"_footer.html":              "",
+		"index.md":                  "# Home",
+		"blog/2022/_ssg.json":       `{"index-sort": "date"}`,
+		"blog/2022/a.md":            ":ssg-date 2022-01-01\n# Old <post>",
+		"blog/2022/b.md":            ":ssg-date 2022-06-01\n# New post",
+		"blog/2022/c.md":            ":ssg-title Undated\n# Undated h1",
+		"blog/2022/d.html":          "<h1>Plain HTML</h1>",
+		"blog/2022/e.md":            "# Shadowed by e.html",
+		"blog/2022/e.html":          "<h1>Preferred</h1>",
+		"blog/2022/image.png":       "png",
+		"blog/2022/draft/x.md":      "# X",
+		"blog/2022/draft/_ssg.json": `{"index": false}`,
+		"blog/2023/index.md":        "# 2023",
+		"blog/2023/post.md":         "# Post",
+		"blog/2024/01/index.md":     "# January",
+		"blog/images/a.png":         "png",
+		"blog/wip/_ssg.json":        `{"draft": true}`,
+		"blog/wip/post.md":          "# WIP",
+		"notes/_ssg.json":           `{"index-sort": "title"}`,
+		"notes/z.md":                "# Alpha",
+		"notes/a.md":                "# Zulu",
+	})
+
+	err := os.MkdirAll(filepath.Join(src, "blog", "empty"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(src, dst, "TestAutoIndex", "https://example.com")
+	s.With(Caching(true), AutoIndex(true))
+	_, outputs, err := s.Build(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expecteds := map[string]string{
+		"blog/index.html": `<title>blog</title>
+<h1>blog</h1>
+<ul class="ssg-index">
+<li><a href="2022/">2022/</a></li>
+<li><a href="2023/">2023/</a></li>
+<li><a href="2024/">2024/</a></li>
+</ul>
+`,
+		"blog/2024/index.html": `<title>2024</title>
+<h1>2024</h1>
+<ul class="ssg-index">
+<li><a href="01/">01/</a></li>
+</ul>
+`,
+		"blog/2022/index.html": `<title>2022</title>
+<h1>2022</h1>
+<ul class="ssg-index">
+<li><time datetime="2022-06-01">2022-06-01</time> <a href="b.html">New post</a></li>
+<li><time datetime="2022-01-01">2022-01-01</time> <a href="a.html">Old &lt;post&gt;</a></li>
+<li><a href="c.html">Undated</a></li>
+<li><a href="d.html">Plain HTML</a></li>
+<li><a href="e.html">Preferred</a></li>
+</ul>
+`,
+		"notes/index.html": `<title>notes</title>
+<h1>notes</h1>
+<ul class="ssg-index">
+<li><a href="z.html">Alpha</a></li>
+<li><a href="a.html">Zulu</a></li>
+</ul>
+`,
+	}
+
+	generated := make(map[string]bool)
+	for i := range outputs {
+		o := &outputs[i]
+		rel, err := filepath.Rel(dst, o.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rel == "blog/2022/draft/index.html" || rel == "blog/2023/index.html" && strings.Contains(string(o.data), "ssg-index") {
+			t.Fatalf("unexpected generated index '%s'", rel)
+		}
+		if rel == "blog/2022/a.html" && strings.Contains(string(o.data), DirectiveDate) {
+			t.Fatalf("unexpected date directive in '%s'", rel)
+		}
+		expected, ok := expecteds[rel]
+		if !ok {
+			continue
+		}
+		generated[rel] = true
+		if string(o.data) != expected {
+			t.Logf("expected:\n%s", expected)
+			t.Logf("actual:\n%s", o.data)
+			t.Fatalf("unexpected output data for '%s'", rel)
+		}
+	}
+	for rel := range expecteds {
+		if !generated[rel] {
+			t.Fatalf("missing generated index '%s'", rel)
+		}
+	}
+
+	_, outputs, err = Build(src, filepath.Join(t.TempDir(), "dst"), "TestAutoIndex", "https://example.com", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := range outputs {
+		if strings.Contains(string(outputs[i].data), "ssg-index") {
+			t.Fatalf("unexpected generated index '%s' without AutoIndex", outputs[i].target)
+		}
+	}
+
+	writeFiles(t, src, map[string]string{"notes/bad.md": ":ssg-date yesterday\n# Bad"})
+	_, _, err = Build(src, filepath.Join(t.TempDir(), "dst"), "TestAutoIndex", "https://example.com", nil, AutoIndex(true))
+	if err == nil {
+		t.Fatalf("unexpected nil error for bad date")
+	}
+}
+
+func TestAutoIndexPagination(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	writeFiles(t, src, map[string]string{
+		"_header.html":    "",
+		"_footer.html":    "",
+		"index.md":        "# Home",
+		"blog/_ssg.json":  `{"index-page-size": 2}`,
+		"blog/1.md":       "# One",
+		"blog/2.md":       "# Two",
+		"blog/3.md":       "# Three",
+		"blog/4.md":       "# Four",
+		"blog/5.md":       "# Five",
+		"blog/extra/x.md": "# X",
+	})
+
+	s := New(src, dst, "TestAutoIndexPagination", "https://example.com")
+	s.With(Caching(true), AutoIndex(true))
+	_, outputs, err := s.Build(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expecteds := map[string]string{
+		"blog/index.html": `<h1>blog</h1>
+<ul class="ssg-index">
+<li><a href="extra/">extra/</a></li>
+<li><a href="1.html">One</a></li>
+</ul>
+<nav class="ssg-pagination">
+<a rel="next" href="page/2/">Next</a>
+</nav>
+`,
+		"blog/page/2/index.html": `<h1>blog - page 2</h1>
+<ul class="ssg-index">
+<li><a href="../../2.html">Two</a></li>
+<li><a href="../../3.html">Three</a></li>
+</ul>
+<nav class="ssg-pagination">
+<a rel="prev" href="../../">Previous</a>
+<a rel="next" href="../3/">Next</a>
+</nav>
+`,
+		"blog/page/3/index.html": `<h1>blog - page 3</h1>
+<ul class="ssg-index">
+<li><a href="../../4.html">Four</a></li>
+<li><a href="../../5.html">Five</a></li>
+</ul>
+<nav class="ssg-pagination">
+<a rel="prev" href="../2/">Previous</a>
+</nav>
+`,
+		"blog/extra/index.html": `<h1>extra</h1>
+<ul class="ssg-index">
+<li><a href="x.html">X</a></li>
+</ul>
+`,
+	}
+
+	generated := make(map[string]bool)
+	for i := range outputs {
+		o := &outputs[i]
+		rel, err := filepath.Rel(dst, o.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, ok := expecteds[rel]
+		if !ok {
+			continue
+		}
+		generated[rel] = true
+		if string(o.data) != expected {
+			t.Logf("expected:\n%s", expected)
+			t.Logf("actual:\n%s", o.data)
+			t.Fatalf("unexpected output data for '%s'", rel)
+		}
+	}
+	if len(generated) != len(expecteds) {
+		t.Fatalf("unexpected number of generated indexes: expected=%d, actual=%d", len(expecteds), len(generated))
+	}
+
+	sitemap, err := Sitemap(dst, s.URL, time.Now(), outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, loc := range []string{"/blog/", "/blog/page/2/", "/blog/page/3/"} {
+		if !strings.Contains(sitemap, "<loc>https://example.com"+loc) {
+			t.Fatalf("missing '%s' in sitemap:\n%s", loc, sitemap)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package ssg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MarkerLayouts is the directory of named layouts at the root of src.
+// Layout "foo" is defined by _layouts/foo.header.html and/or _layouts/foo.footer.html.
+const MarkerLayouts = "_layouts"
+
+var ErrUnknownLayout = errors.New("unknown layout")
+
+// layout is a named header/footer pair.
+// Nil header or footer falls back to the cascading template.
+type layout struct {
+	header *header
+	footer *footer
+}
+
+// collectLayouts reads named layouts from MarkerLayouts
+func (s *Ssg) collectLayouts() error {
+	s.layouts = make(map[string]layout)
+	dir := filepath.Join(s.Src, MarkerLayouts)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for i := range entries {
+		base := entries[i].Name()
+		path := filepath.Join(dir, base)
+
+		switch {
+		case strings.HasSuffix(base, ExtPageHeader):
+			h, err := s.readHeader(path)
+			if err != nil {
+				return err
+			}
+			name := strings.TrimSuffix(base, ExtPageHeader)
+			l := s.layouts[name]
+			l.header = &h
+			s.layouts[name] = l
+
+		case strings.HasSuffix(base, ExtPageFooter):
+			f, err := s.readFooter(path)
+			if err != nil {
+				return err
+			}
+			name := strings.TrimSuffix(base, ExtPageFooter)
+			l := s.layouts[name]
+			l.footer = &f
+			s.layouts[name] = l
+		}
+	}
+
+	return nil
+}
+
+// templates returns header and footer for page at path with extension ext.
+//
+// If the page selects a layout, or a layout is configured in _ssg.json,
+// the layout's templates take precedence over cascading templates.
+// Per-page overrides, e.g. foo.header.html for foo.md,
+// take precedence over both.
+func (s *Ssg) templates(path string, ext string, layoutName string) (header, footer, error) {
+	h := s.headers.choose(path)
+	f := s.footers.choose(path)
+
+	if ext == "" {
+		return h, f, nil
+	}
+
+	if layoutName == "" {
+		if c := s.configs.choose(path); c.Layout != nil {
+			layoutName = *c.Layout
+		}
+	}
+	if layoutName != "" {
+		l, ok := s.layouts[layoutName]
+		if !ok {
+			return h, f, fmt.Errorf("%w '%s'", ErrUnknownLayout, layoutName)
+		}
+		if l.header != nil {
+			h = *l.header
+		}
+		if l.footer != nil {
+			f = *l.footer
+		}
+	}
+
+	stem := strings.TrimSuffix(path, ext)
+	if override, ok := s.pageHeaders[stem+ExtPageHeader]; ok {
+		h = override
+	}
+	if override, ok := s.pageFooters[stem+ExtPageFooter]; ok {
+		f = override
+	}
+	return h, f, nil
+}
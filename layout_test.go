@@ -0,0 +1,89 @@
+package ssg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLayouts(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	files := map[string]string{
+		"_header.html":                 "<!-- ROOT HEADER -->\n",
+		"_footer.html":                 "<!-- ROOT FOOTER -->\n",
+		"_layouts/article.header.html": "<!-- ARTICLE HEADER -->\n",
+		"_layouts/article.footer.html": "<!-- ARTICLE FOOTER -->\n",
+		"_layouts/wide.header.html":    "<!-- WIDE HEADER -->\n",
+		"index.md":                     "# Home",
+		"post.md":                      ":ssg-layout article\n# Post",
+		"wide.md":                      ":ssg-layout wide\n# Wide",
+		"special.md":                   ":ssg-layout article\n# Special",
+		"special.header.html":          "<!-- SPECIAL HEADER -->\n",
+		"blog/_ssg.json":               `{"layout": "article"}`,
+		"blog/index.md":                "# Blog",
+		"blog/plain/_ssg.json":         `{"layout": ""}`,
+		"blog/plain/index.md":          "# Plain",
+	}
+	for name, content := range files {
+		path := filepath.Join(src, name)
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := New(src, dst, "TestLayouts", "https://example.com")
+	s.With(Caching(true))
+	_, outputs, err := s.Build(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expecteds := map[string]string{
+		"index.html":            "<!-- ROOT HEADER -->\n<h1 id=\"home\">Home</h1>\n<!-- ROOT FOOTER -->\n",
+		"post.html":             "<!-- ARTICLE HEADER -->\n<h1 id=\"post\">Post</h1>\n<!-- ARTICLE FOOTER -->\n",
+		"wide.html":             "<!-- WIDE HEADER -->\n<h1 id=\"wide\">Wide</h1>\n<!-- ROOT FOOTER -->\n",
+		"special.html":          "<!-- SPECIAL HEADER -->\n<h1 id=\"special\">Special</h1>\n<!-- ARTICLE FOOTER -->\n",
+		"blog/index.html":       "<!-- ARTICLE HEADER -->\n<h1 id=\"blog\">Blog</h1>\n<!-- ARTICLE FOOTER -->\n",
+		"blog/plain/index.html": "<!-- ROOT HEADER -->\n<h1 id=\"plain\">Plain</h1>\n<!-- ROOT FOOTER -->\n",
+	}
+
+	if len(outputs) != len(expecteds) {
+		for i := range outputs {
+			t.Logf("output: %s", outputs[i].target)
+		}
+		t.Fatalf("unexpected number of outputs: expected=%d, actual=%d", len(expecteds), len(outputs))
+	}
+	for i := range outputs {
+		o := &outputs[i]
+		rel, err := filepath.Rel(dst, o.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, ok := expecteds[rel]
+		if !ok {
+			t.Fatalf("unexpected output '%s'", rel)
+		}
+		if string(o.data) != expected {
+			t.Logf("expected:\n%s", expected)
+			t.Logf("actual:\n%s", o.data)
+			t.Fatalf("unexpected output data for '%s'", rel)
+		}
+	}
+
+	err = os.WriteFile(filepath.Join(src, "unknown.md"), []byte(":ssg-layout unknown\n# Unknown"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = Build(src, dst, "TestLayouts", "https://example.com", nil)
+	if !errors.Is(err, ErrUnknownLayout) {
+		t.Fatalf("unexpected error for unknown layout: %v", err)
+	}
+}
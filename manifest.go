@@ -0,0 +1,352 @@
+package ssg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+type (
+	// Manifest describes multiple sites to be built, keyed by site key
+	Manifest map[string]Site
+
+	// Site is a manifest entry describing how to build a site
+	Site struct {
+		Name    string                 `json:"name"`
+		URL     string                 `json:"url"`
+		Src     string                 `json:"src"`
+		Dst     string                 `json:"dst"`
+		Cleanup bool                   `json:"cleanup"`
+		Copies  map[string]CopyTargets `json:"copies"`
+
+		// Replaces are ${{ key }} replacements applied to all files.
+		// Unknown keys are reported as warnings.
+		Replaces Replacements `json:"replaces"`
+
+		// GenerateIndex enables [AutoIndex] for the site
+		GenerateIndex bool `json:"generate-index"`
+
+		// Symlinks is the site's [SymlinkPolicy]: "ignore", "follow" or "copy"
+		Symlinks SymlinkPolicy `json:"symlinks"`
+	}
+
+	// CopyTarget is where a file or directory is copied to before build.
+	// If Force is false, the copy fails if Target already exists.
+	CopyTarget struct {
+		Target string `json:"target"`
+		Force  bool   `json:"force"`
+	}
+
+	// CopyTargets is a list of copy targets for a single copy source.
+	//
+	// In JSON, it can be a target string, a CopyTarget object,
+	// or an array of target strings and objects.
+	CopyTargets []CopyTarget
+)
+
+// ParseManifest reads, parses and validates manifest at path.
+// Relative paths in the manifest are resolved relative to the manifest's directory.
+func ParseManifest(path string) (Manifest, error) {
+	data, err := ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	m, err := NewManifest(data)
+	if err != nil {
+		return nil, fmt.Errorf("bad manifest %s: %w", path, err)
+	}
+	m.resolve(filepath.Dir(path))
+	return m, nil
+}
+
+// NewManifest parses and validates manifest data
+func NewManifest(data []byte) (Manifest, error) {
+	m := Manifest{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&m)
+	if err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("unexpected data after manifest")
+	}
+	err = m.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Validate validates all sites in m
+func (m Manifest) Validate() error {
+	if len(m) == 0 {
+		return errors.New("no sites in manifest")
+	}
+	for _, key := range m.keys() {
+		err := m[key].Validate()
+		if err != nil {
+			return fmt.Errorf("site '%s': %w", key, err)
+		}
+	}
+	return nil
+}
+
+// Validate validates s
+func (s Site) Validate() error {
+	if s.Src == "" {
+		return errors.New("empty src")
+	}
+	if s.Dst == "" {
+		return errors.New("empty dst")
+	}
+	if filepath.Clean(s.Src) == filepath.Clean(s.Dst) {
+		return fmt.Errorf("src is identical to dst: '%s'", s.Src)
+	}
+	for from, targets := range s.Copies {
+		if from == "" {
+			return errors.New("empty copy source")
+		}
+		if len(targets) == 0 {
+			return fmt.Errorf("copy '%s': no targets", from)
+		}
+		for i := range targets {
+			if targets[i].Target == "" {
+				return fmt.Errorf("copy '%s': empty target at index %d", from, i)
+			}
+		}
+	}
+	err := s.Replaces.Validate()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Build builds all sites in m with opts.
+// If concurrent is true, the sites are built concurrently.
+func (m Manifest) Build(concurrent bool, opts ...Option) error {
+	keys := m.keys()
+	if !concurrent {
+		for _, key := range keys {
+			err := m[key].Build(opts...)
+			if err != nil {
+				return fmt.Errorf("site '%s': %w", key, err)
+			}
+		}
+		return nil
+	}
+
+	wg := new(sync.WaitGroup)
+	errs := make([]error, len(keys))
+	for i, key := range keys {
+		wg.Add(1)
+		go func(i int, key string) {
+			defer wg.Done()
+			err := m[key].Build(opts...)
+			if err != nil {
+				errs[i] = fmt.Errorf("site '%s': %w", key, err)
+			}
+		}(i, key)
+	}
+
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Build performs pre-build copies and cleanup, and then generates s.
+// If s has replacements, the replacement hook is prepended to the hooks in opts.
+func (s Site) Build(opts ...Option) error {
+	if len(s.Replaces) != 0 {
+		hook, err := ReplaceHook(s.Replaces, ReplaceUnknownWarn)
+		if err != nil {
+			return fmt.Errorf("replaces: %w", err)
+		}
+		opts = append(opts, PrependHooks(hook))
+	}
+	if s.GenerateIndex {
+		opts = append([]Option{AutoIndex(true)}, opts...)
+	}
+	if s.Symlinks != SymlinkIgnore {
+		opts = append([]Option{Symlinks(s.Symlinks)}, opts...)
+	}
+	if s.Cleanup {
+		// Cleanup must never remove src
+		_, err := checkLayout(s.Src, s.Dst)
+		if err != nil && !errors.Is(err, ErrDstInSrc) {
+			return fmt.Errorf("cleanup dst '%s': %w", s.Dst, err)
+		}
+	}
+	for _, from := range sortedKeys(s.Copies) {
+		for _, target := range s.Copies[from] {
+			err := Copy(from, target.Target, target.Force)
+			if err != nil {
+				return fmt.Errorf("copy '%s' -> '%s': %w", from, target.Target, err)
+			}
+		}
+	}
+	if s.Cleanup {
+		err := os.RemoveAll(s.Dst)
+		if err != nil {
+			return fmt.Errorf("cleanup dst '%s': %w", s.Dst, err)
+		}
+	}
+	return Generate(s.Src, s.Dst, s.Name, s.URL, opts...)
+}
+
+// Copy copies file or directory from to target.
+// If target exists and force is false, Copy returns an error.
+// If force is true, existing target is replaced.
+func Copy(from, target string, force bool) error {
+	stat, err := os.Stat(from)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stat(target)
+	switch {
+	case err == nil && !force:
+		return fmt.Errorf("target '%s' already exists", target)
+	case err == nil:
+		err = os.RemoveAll(target)
+		if err != nil {
+			return err
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	if !stat.IsDir() {
+		return copyFile(from, target, stat.Mode().Perm())
+	}
+
+	return filepath.WalkDir(from, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(target, rel)
+		if d.IsDir() {
+			return os.MkdirAll(dst, info.Mode().Perm())
+		}
+		return copyFile(path, dst, info.Mode().Perm())
+	})
+}
+
+func copyFile(from, target string, perm fs.FileMode) error {
+	data, err := ReadFile(from)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(target), os.ModePerm)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(target, data, perm)
+}
+
+func (c *CopyTargets) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) != 0 && data[0] == '[' {
+		var raws []json.RawMessage
+		err := json.Unmarshal(data, &raws)
+		if err != nil {
+			return err
+		}
+		targets := make(CopyTargets, len(raws))
+		for i := range raws {
+			err = targets[i].UnmarshalJSON(raws[i])
+			if err != nil {
+				return fmt.Errorf("copy target at index %d: %w", i, err)
+			}
+		}
+		*c = targets
+		return nil
+	}
+
+	var target CopyTarget
+	err := target.UnmarshalJSON(data)
+	if err != nil {
+		return err
+	}
+	*c = CopyTargets{target}
+	return nil
+}
+
+func (c *CopyTarget) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) != 0 && data[0] == '"' {
+		c.Force = false
+		return json.Unmarshal(data, &c.Target)
+	}
+
+	type copyTarget CopyTarget // Avoid recursion
+	var target copyTarget
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&target)
+	if err != nil {
+		return fmt.Errorf("expecting target string or object with 'target' and 'force': %w", err)
+	}
+	*c = CopyTarget(target)
+	return nil
+}
+
+// resolve resolves relative paths in m relative to dir
+func (m Manifest) resolve(dir string) {
+	join := func(path string) string {
+		if filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(dir, path)
+	}
+
+	for key, site := range m {
+		site.Src = join(site.Src)
+		site.Dst = join(site.Dst)
+		copies := make(map[string]CopyTargets, len(site.Copies))
+		for from, targets := range site.Copies {
+			resolved := make(CopyTargets, len(targets))
+			for i := range targets {
+				resolved[i] = CopyTarget{
+					Target: join(targets[i].Target),
+					Force:  targets[i].Force,
+				}
+			}
+			copies[join(from)] = resolved
+		}
+		site.Copies = copies
+		for k, r := range site.Replaces {
+			if r.File != "" {
+				r.File = join(r.File)
+				site.Replaces[k] = r
+			}
+		}
+		m[key] = site
+	}
+}
+
+func (m Manifest) keys() []string {
+	return sortedKeys(m)
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
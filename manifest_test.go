@@ -0,0 +1,189 @@
+package ssg_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/soyart/ssg-go"
+)
+
+func TestParseManifest(t *testing.T) {
+	m, err := ssg.ParseManifest("./testdata/manifest.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	site, ok := m["johndoe.com"]
+	if !ok {
+		t.Fatalf("missing site johndoe.com")
+	}
+	if site.Name != "JohnDoe.com" || site.URL != "https://johndoe.com" || !site.Cleanup {
+		t.Fatalf("unexpected site %+v", site)
+	}
+	if site.Src != "testdata/johndoe.com/src" || site.Dst != "testdata/johndoe.com/dst" {
+		t.Fatalf("unexpected src or dst, src='%s', dst='%s'", site.Src, site.Dst)
+	}
+
+	expecteds := map[string]ssg.CopyTargets{
+		"testdata/assets/some.txt": {
+			{Target: "testdata/johndoe.com/src/some-txt.txt"},
+		},
+		"testdata/assets/some": {
+			{Target: "testdata/johndoe.com/src/drop", Force: true},
+		},
+		"testdata/assets/style.css": {
+			{Target: "testdata/johndoe.com/src/style.css", Force: true},
+			{Target: "testdata/johndoe.com/src/style-copy-0.css", Force: true},
+			{Target: "testdata/johndoe.com/src/style-copy-1.css"},
+		},
+	}
+
+	if len(site.Copies) != len(expecteds) {
+		t.Fatalf("unexpected number of copies %d", len(site.Copies))
+	}
+	for from, expected := range expecteds {
+		actual, ok := site.Copies[from]
+		if !ok {
+			t.Fatalf("missing copy from '%s'", from)
+		}
+		if len(actual) != len(expected) {
+			t.Fatalf("unexpected number of targets for '%s': %d", from, len(actual))
+		}
+		for i := range expected {
+			if actual[i] != expected[i] {
+				t.Fatalf("unexpected target for '%s'[%d]: expected=%+v, actual=%+v", from, i, expected[i], actual[i])
+			}
+		}
+	}
+}
+
+func TestManifestValidate(t *testing.T) {
+	tests := map[string]string{
+		`{}`:                      "no sites",
+		`{"foo": {"dst": "dst"}}`: "empty src",
+		`{"foo": {"src": "src"}}`: "empty dst",
+		`{"foo": {"src": "src", "dst": "./src"}}`:                           "identical",
+		`{"foo": {"src": "src", "dst": "dst", "copies": {"a": []}}}`:        "no targets",
+		`{"foo": {"src": "src", "dst": "dst", "copies": {"a": ""}}}`:        "empty target",
+		`{"foo": {"src": "src", "dst": "dst", "copies": {"a": 1}}}`:         "expecting target",
+		`{"foo": {"src": "src", "dst": "dst", "copies": {"a": {"t": ""}}}}`: "expecting target",
+		`{"foo": {"src": "src", "dst": "dst", "cleanup": "yes"}}`:           "cannot unmarshal",
+		`{"foo": {"src": "src", "dst": "dst", "generate-indx": true}}`:      "unknown field",
+		`{"foo": {"src": "src", "dst": "dst"}} {}`:                          "unexpected data",
+	}
+
+	for manifest, expected := range tests {
+		_, err := ssg.NewManifest([]byte(manifest))
+		if err == nil {
+			t.Fatalf("unexpected nil error for manifest %s", manifest)
+		}
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("unexpected error for manifest %s: '%v', expecting '%s'", manifest, err, expected)
+		}
+	}
+}
+
+func TestManifestBuild(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"assets/style.css":       "body {}",
+		"assets/fonts/font.ttf":  "fake font",
+		"site1/src/index.md":     "# Site 1",
+		"site2/src/index.md":     "# Site 2",
+		"site2/dst/stale.html":   "stale",
+		"site2/src/style.css":    "old style",
+		"manifest.json":          manifestBuild,
+		"manifest-no-force.json": manifestNoForce,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, err := ssg.ParseManifest(filepath.Join(root, "manifest.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = m.Build(true)
+	if err != nil {
+		t.Fatalf("unexpected build error: %v", err)
+	}
+
+	expecteds := map[string]string{
+		"site1/dst/index.html":     "<title>Site 1</title>",
+		"site1/dst/style.css":      "body {}",
+		"site1/dst/fonts/font.ttf": "fake font",
+		"site1/dst/sitemap.xml":    "https://site1.com/",
+		"site2/dst/index.html":     "<title>Site 2</title>",
+		"site2/dst/style.css":      "body {}",
+		"site2/dst/style-copy.css": "body {}",
+	}
+	for name, expected := range expecteds {
+		data, err := os.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			t.Fatalf("failed to read output '%s': %v", name, err)
+		}
+		if !strings.Contains(string(data), expected) {
+			t.Fatalf("missing expected substr '%s' from '%s'", expected, name)
+		}
+	}
+
+	_, err = os.Stat(filepath.Join(root, "site2/dst/stale.html"))
+	if !os.IsNotExist(err) {
+		t.Fatalf("expecting stale.html to be cleaned up, got err=%v", err)
+	}
+
+	m, err = ssg.ParseManifest(filepath.Join(root, "manifest-no-force.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = m.Build(false)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expecting error for existing copy target, got %v", err)
+	}
+}
+
+const manifestBuild = `{
+	"site1": {
+		"name": "Site1",
+		"url": "https://site1.com",
+		"src": "site1/src",
+		"dst": "site1/dst",
+		"copies": {
+			"assets/style.css": "site1/src/style.css",
+			"assets/fonts": {"target": "site1/src/fonts"}
+		}
+	},
+	"site2": {
+		"name": "Site2",
+		"url": "https://site2.com",
+		"src": "site2/src",
+		"dst": "site2/dst",
+		"cleanup": true,
+		"copies": {
+			"assets/style.css": [
+				{"target": "site2/src/style.css", "force": true},
+				"site2/src/style-copy.css"
+			]
+		}
+	}
+}`
+
+const manifestNoForce = `{
+	"site2": {
+		"src": "site2/src",
+		"dst": "site2/dst",
+		"copies": {
+			"assets/style.css": "site2/src/style.css"
+		}
+	}
+}`
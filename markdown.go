@@ -0,0 +1,211 @@
+package ssg
+
+import (
+	"bytes"
+	"html"
+	"strings"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	mdhtml "github.com/gomarkdown/markdown/html"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+const (
+	// PlaceholderTOC is replaced with table of contents
+	// if it is the only content of a Markdown paragraph
+	PlaceholderTOC = "{{toc}}"
+
+	classAnchor = "ssg-anchor"
+	classTOC    = "ssg-toc"
+)
+
+// Markdown configures how ssg-go parses Markdown
+// and renders the parsed document to HTML.
+//
+// The zero value parses with no extensions and renders with no flags.
+// Use [DefaultMarkdown] to get the default ssg-go dialect.
+type Markdown struct {
+	// Extensions is the gomarkdown parser extensions, e.g. parser.Footnotes
+	// or parser.HardLineBreak
+	Extensions parser.Extensions
+
+	// Flags is the gomarkdown HTML renderer flags, e.g. html.Smartypants,
+	// html.HrefTargetBlank, html.SkipHTML or html.Safelink
+	Flags mdhtml.Flags
+
+	// HeadingAnchor, if not empty, is the text of anchor links
+	// appended to headings with ids, e.g. "#" or "¶".
+	// The links have class "ssg-anchor".
+	HeadingAnchor string
+
+	// TOCDepth is the deepest heading level listed in
+	// the table of contents. 0 means all levels.
+	TOCDepth int
+}
+
+type tocEntry struct {
+	level int
+	id    string
+	text  string
+}
+
+// DefaultMarkdown returns the default ssg-go Markdown dialect,
+// i.e. [SsgExtensions] and [HTMLFlags].
+func DefaultMarkdown() Markdown {
+	return Markdown{
+		Extensions: SsgExtensions,
+		Flags:      HTMLFlags,
+	}
+}
+
+// ToHTML converts md (Markdown) into HTML document
+// using the default dialect
+func ToHTML(md []byte) []byte {
+	return DefaultMarkdown().ToHTML(md)
+}
+
+// ToHTML converts md (Markdown) into HTML document
+// using m's parser extensions and renderer flags.
+//
+// Paragraphs containing only [PlaceholderTOC] are replaced with
+// nested list of links to the document's headings.
+func (m Markdown) ToHTML(md []byte) []byte {
+	root := markdown.Parse(md, parser.NewWithExtensions(m.Extensions))
+	toc := m.headings(root)
+	if bytes.Contains(md, []byte(PlaceholderTOC)) {
+		replaceTOC(root, m.renderTOC(toc))
+	}
+
+	renderer := mdhtml.NewRenderer(mdhtml.RendererOptions{
+		Flags: m.Flags,
+	})
+	return markdown.Render(root, renderer)
+}
+
+// headings collects headings with ids from root,
+// appending anchor links to them if m.HeadingAnchor is set.
+func (m Markdown) headings(root ast.Node) []tocEntry {
+	var toc []tocEntry
+	ast.WalkFunc(root, func(node ast.Node, entering bool) ast.WalkStatus {
+		heading, ok := node.(*ast.Heading)
+		if !ok || !entering || heading.HeadingID == "" {
+			return ast.GoToNext
+		}
+
+		toc = append(toc, tocEntry{
+			level: heading.Level,
+			id:    heading.HeadingID,
+			text:  textContent(heading),
+		})
+
+		if m.HeadingAnchor != "" {
+			anchor := &ast.Link{
+				Destination:          []byte("#" + heading.HeadingID),
+				AdditionalAttributes: []string{`class="` + classAnchor + `"`},
+			}
+			ast.AppendChild(anchor, &ast.Text{Leaf: ast.Leaf{Literal: []byte(m.HeadingAnchor)}})
+			ast.AppendChild(heading, &ast.Text{Leaf: ast.Leaf{Literal: []byte(" ")}})
+			ast.AppendChild(heading, anchor)
+		}
+
+		return ast.SkipChildren
+	})
+
+	return toc
+}
+
+// renderTOC renders toc entries as nested HTML lists
+func (m Markdown) renderTOC(toc []tocEntry) []byte {
+	buf := bytes.NewBufferString(`<nav class="` + classTOC + `">` + "\n")
+
+	// levels is the stack of heading levels of currently opened lists
+	var levels []int
+	for _, entry := range toc {
+		if m.TOCDepth > 0 && entry.level > m.TOCDepth {
+			continue
+		}
+
+		switch {
+		case len(levels) == 0:
+			buf.WriteString("<ul>\n")
+			levels = append(levels, entry.level)
+
+		case entry.level > levels[len(levels)-1]:
+			buf.WriteString("\n<ul>\n")
+			levels = append(levels, entry.level)
+
+		default:
+			buf.WriteString("</li>\n")
+			// Close lists until entry is under its nearest shallower heading
+			for len(levels) > 1 && entry.level <= levels[len(levels)-2] {
+				buf.WriteString("</ul>\n</li>\n")
+				levels = levels[:len(levels)-1]
+			}
+			// Entries between levels, e.g. h2 after h1 and h3,
+			// are listed with the deeper entries under the same parent
+			if entry.level < levels[len(levels)-1] {
+				levels[len(levels)-1] = entry.level
+			}
+		}
+
+		Fprintf(buf, `<li><a href="#%s">%s</a>`, html.EscapeString(entry.id), html.EscapeString(entry.text))
+	}
+
+	if len(levels) != 0 {
+		buf.WriteString("</li>\n")
+		for i := 1; i < len(levels); i++ {
+			buf.WriteString("</ul>\n</li>\n")
+		}
+		buf.WriteString("</ul>\n")
+	}
+
+	buf.WriteString("</nav>")
+	return buf.Bytes()
+}
+
+// replaceTOC replaces paragraphs containing only PlaceholderTOC with toc
+func replaceTOC(root ast.Node, toc []byte) {
+	var placeholders []ast.Node
+	ast.WalkFunc(root, func(node ast.Node, entering bool) ast.WalkStatus {
+		paragraph, ok := node.(*ast.Paragraph)
+		if !ok || !entering {
+			return ast.GoToNext
+		}
+		if strings.TrimSpace(textContent(paragraph)) == PlaceholderTOC {
+			placeholders = append(placeholders, paragraph)
+		}
+		return ast.SkipChildren
+	})
+
+	for _, p := range placeholders {
+		block := &ast.HTMLBlock{Leaf: ast.Leaf{Literal: toc}}
+		parent := p.GetParent()
+		children := parent.GetChildren()
+		for i := range children {
+			if children[i] == p {
+				children[i] = block
+				block.SetParent(parent)
+				break
+			}
+		}
+	}
+}
+
+// textContent returns concatenated literal text under node
+func textContent(node ast.Node) string {
+	var b strings.Builder
+	ast.WalkFunc(node, func(n ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		switch leaf := n.(type) {
+		case *ast.Text:
+			b.Write(leaf.Literal)
+		case *ast.Code:
+			b.Write(leaf.Literal)
+		}
+		return ast.GoToNext
+	})
+	return b.String()
+}
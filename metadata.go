@@ -2,11 +2,16 @@ package ssg
 
 import (
 	"bytes"
+	"errors"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 )
 
+// ErrEmptyFile is returned by [DotFiles] if a file path is empty
+var ErrEmptyFile = errors.New("empty file path")
+
 func GenerateMetadata(
 	src string,
 	dst string,
@@ -70,10 +75,17 @@ xmlns="https://www.sitemaps.org/schemas/sitemap/0.9">
 `)
 	for i := range outputs {
 		o := &outputs[i]
+		if o.noSitemap {
+			continue
+		}
 		target, err := filepath.Rel(dst, o.target)
 		if err != nil {
 			return sm.String(), err
 		}
+		// Skip outputs outside of dst, e.g. gemtext outputs
+		if target == ".." || strings.HasPrefix(target, ".."+string(filepath.Separator)) {
+			continue
+		}
 
 		Fprintf(sm, "<url><loc>%s/", url)
 
@@ -108,7 +120,7 @@ func DotFiles(src string, files []string) (string, error) {
 	list := bytes.NewBuffer(nil)
 	for _, f := range files {
 		if f == "" {
-			panic("found empty file for .files")
+			return "", ErrEmptyFile
 		}
 		rel, err := filepath.Rel(src, f)
 		if err != nil {
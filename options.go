@@ -1,13 +1,23 @@
 package ssg
 
 import (
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strconv"
+	"time"
+
+	"github.com/gomarkdown/markdown/html"
+	"github.com/gomarkdown/markdown/parser"
 )
 
+// ErrInvalidPipeline is returned by Build and Generate
+// if [WithPipelines] is given a value that is not a pipeline
+var ErrInvalidPipeline = errors.New("invalid pipeline")
+
 type (
 	Option func(*Ssg)
 
@@ -32,23 +42,64 @@ type (
 		Pipelines() []Pipeline
 		Caching() bool
 		Writers() int
+		Markdown() Markdown
+		Converters() map[string]Converter
+		Gemini() string
+		AutoIndex() bool
+		Tags() string
+		Drafts() bool
+		Now() time.Time
+		PrettyURLs() bool
+		RedirectFormats() []RedirectFormat
+		Collisions() CollisionPolicy
+		ContinueOnError() bool
+		AllowDstInSrc() bool
+		Symlinks() SymlinkPolicy
 	}
 
 	options struct {
 		// outputs      Outputs
-		hooks        []Hook
-		hookGenerate []HookGenerate
-		pipelines    []Pipeline
-		caching      bool
-		writers      int
+		hooks           []Hook
+		hookGenerate    []HookGenerate
+		pipelines       []Pipeline
+		caching         bool
+		writers         int
+		markdown        Markdown
+		converters      map[string]Converter
+		gemini          string
+		autoIndex       bool
+		tags            string
+		tagsLayout      string
+		tagsFeed        bool
+		drafts          bool
+		now             time.Time
+		prettyURLs      bool
+		redirectFormats []RedirectFormat
+		collisions      CollisionPolicy
+		continueOnError bool
+		allowDstInSrc   bool
+		symlinks        SymlinkPolicy
 	}
 )
 
-func (o options) Hooks() []Hook                 { return o.hooks }
-func (o options) HooksGenerate() []HookGenerate { return o.hookGenerate }
-func (o options) Pipelines() []Pipeline         { return o.pipelines }
-func (o options) Caching() bool                 { return o.caching }
-func (o options) Writers() int                  { return o.writers }
+func (o options) Hooks() []Hook                     { return o.hooks }
+func (o options) HooksGenerate() []HookGenerate     { return o.hookGenerate }
+func (o options) Pipelines() []Pipeline             { return o.pipelines }
+func (o options) Caching() bool                     { return o.caching }
+func (o options) Writers() int                      { return o.writers }
+func (o options) Markdown() Markdown                { return o.markdown }
+func (o options) Converters() map[string]Converter  { return o.converters }
+func (o options) Gemini() string                    { return o.gemini }
+func (o options) AutoIndex() bool                   { return o.autoIndex }
+func (o options) Tags() string                      { return o.tags }
+func (o options) Drafts() bool                      { return o.drafts }
+func (o options) Now() time.Time                    { return o.now }
+func (o options) PrettyURLs() bool                  { return o.prettyURLs }
+func (o options) RedirectFormats() []RedirectFormat { return o.redirectFormats }
+func (o options) Collisions() CollisionPolicy       { return o.collisions }
+func (o options) ContinueOnError() bool             { return o.continueOnError }
+func (o options) AllowDstInSrc() bool               { return o.allowDstInSrc }
+func (o options) Symlinks() SymlinkPolicy           { return o.symlinks }
 
 // WritersFromEnv returns an option that sets the parallel writes
 // to whatever [GetEnvWriters] returns
@@ -82,6 +133,141 @@ func Writers(u uint) Option {
 	return func(s *Ssg) { s.options.writers = int(u) }
 }
 
+// WithMarkdown sets the Markdown dialect and HTML renderer flags
+// used to convert Markdown files.
+func WithMarkdown(m Markdown) Option {
+	return func(s *Ssg) { s.options.markdown = m }
+}
+
+// MarkdownExtensions sets the Markdown parser extensions,
+// overwriting the default [SsgExtensions].
+func MarkdownExtensions(ext parser.Extensions) Option {
+	return func(s *Ssg) { s.options.markdown.Extensions = ext }
+}
+
+// MarkdownFlags sets the HTML renderer flags,
+// overwriting the default [HTMLFlags].
+func MarkdownFlags(flags html.Flags) Option {
+	return func(s *Ssg) { s.options.markdown.Flags = flags }
+}
+
+// MarkdownHeadingAnchor appends anchor links with text
+// to Markdown headings with ids.
+func MarkdownHeadingAnchor(text string) Option {
+	return func(s *Ssg) { s.options.markdown.HeadingAnchor = text }
+}
+
+// MarkdownTOCDepth sets the deepest heading level listed
+// in the table of contents rendered for [PlaceholderTOC].
+func MarkdownTOCDepth(depth int) Option {
+	return func(s *Ssg) { s.options.markdown.TOCDepth = depth }
+}
+
+// WithConverter registers c as the [Converter] for files whose names
+// end with ext, e.g. ".txt" or ".frag.html". Converted files are assembled
+// with cascading headers and footers, and written with ".html" extension.
+//
+// Registering ".md" replaces the default Markdown converter.
+func WithConverter(ext string, c Converter) Option {
+	return func(s *Ssg) {
+		if s.options.converters == nil {
+			s.options.converters = make(map[string]Converter)
+		}
+		s.options.converters[ext] = c
+	}
+}
+
+// Gemini enables gemtext output to dst, in parallel with HTML output.
+//
+// Each Markdown file is also rendered to .gmi in the mirrored path under dst,
+// assembled with cascading _header.gmi and _footer.gmi. Other files
+// are mirrored to dst as is. An empty dst disables gemtext output.
+func Gemini(dst string) Option {
+	return func(s *Ssg) {
+		s.options.gemini = ""
+		if dst != "" {
+			s.options.gemini = filepath.Clean(dst)
+		}
+	}
+}
+
+// AutoIndex enables generating index.html for directories
+// without an index page. It can be overridden per directory
+// with "index" in _ssg.json.
+func AutoIndex(enabled bool) Option {
+	return func(s *Ssg) { s.options.autoIndex = enabled }
+}
+
+// Tags enables taxonomy pages for pages with :ssg-tags directive.
+// The tag index and tag pages are generated under dir relative to dst,
+// e.g. tags/index.html and tags/<slug>/index.html.
+func Tags(dir string) Option {
+	return func(s *Ssg) {
+		if dir == "" {
+			s.options.tags = ""
+			return
+		}
+		s.options.tags = filepath.Clean(dir)
+	}
+}
+
+// TagsLayout sets the named layout for taxonomy pages
+func TagsLayout(name string) Option {
+	return func(s *Ssg) { s.options.tagsLayout = name }
+}
+
+// TagsFeed enables RSS feed for each tag, e.g. tags/<slug>/feed.xml
+func TagsFeed(enabled bool) Option {
+	return func(s *Ssg) { s.options.tagsFeed = enabled }
+}
+
+// Drafts includes drafts and pages dated in the future in outputs
+func Drafts(enabled bool) Option {
+	return func(s *Ssg) { s.options.drafts = enabled }
+}
+
+// Now sets the time against which :ssg-date is compared
+// to exclude scheduled pages. Defaults to the build time.
+func Now(t time.Time) Option {
+	return func(s *Ssg) { s.options.now = t }
+}
+
+// PrettyURLs makes converted pages output to foo/index.html instead of foo.html.
+// Relative URLs in the pages are rebased, so co-located assets still resolve.
+func PrettyURLs(enabled bool) Option {
+	return func(s *Ssg) { s.options.prettyURLs = enabled }
+}
+
+// Redirects emits redirect rules from _redirects and page aliases
+// in server configuration formats, in addition to HTML redirect stubs.
+func Redirects(formats ...RedirectFormat) Option {
+	return func(s *Ssg) { s.options.redirectFormats = append(s.options.redirectFormats, formats...) }
+}
+
+// OnCollision sets what happens when multiple outputs have the same target.
+// By default, the build fails with ErrOutputCollision.
+func OnCollision(policy CollisionPolicy) Option {
+	return func(s *Ssg) { s.options.collisions = policy }
+}
+
+// ContinueOnError keeps building after a file fails to build.
+// All failures are then returned together as [BuildErrors].
+func ContinueOnError(enabled bool) Option {
+	return func(s *Ssg) { s.options.continueOnError = enabled }
+}
+
+// AllowDstInSrc allows dst to be inside src, in which case dst is skipped
+// during builds. By default, builds fail with ErrDstInSrc.
+func AllowDstInSrc(enabled bool) Option {
+	return func(s *Ssg) { s.options.allowDstInSrc = enabled }
+}
+
+// Symlinks sets how symlinks in src are handled.
+// By default, symlinks are ignored.
+func Symlinks(policy SymlinkPolicy) Option {
+	return func(s *Ssg) { s.options.symlinks = policy }
+}
+
 // func WithOutputs(c chan<- OutputFile) Option {
 // 	return func(s *Ssg) { s.options.outputs = NewOutputs(c) }
 // }
@@ -128,7 +314,8 @@ func WithPipelines(pipes ...any) Option {
 				pipelines[i] = pipe(s)
 
 			default:
-				panic(fmt.Errorf("unexpected pipelines[%d] type '%s'", i, reflect.TypeOf(p).String()))
+				s.errInit = errors.Join(s.errInit, fmt.Errorf("%w: pipelines[%d] has type '%s'", ErrInvalidPipeline, i, reflect.TypeOf(p)))
+				return
 			}
 		}
 		s.options.pipelines = pipelines
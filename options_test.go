@@ -11,6 +11,9 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/gomarkdown/markdown/html"
+	"github.com/gomarkdown/markdown/parser"
+
 	"github.com/soyart/ssg-go"
 )
 
@@ -282,3 +285,76 @@ func TestChainPipelines(t *testing.T) {
 		panic(err)
 	}
 }
+
+func TestMarkdownOptions(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	md := "# Title\nfirst line\nsecond line\n\n[link](https://example.com)\n"
+	err := os.WriteFile(filepath.Join(src, "index.md"), []byte(md), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		opts     []ssg.Option
+		contains []string
+		excludes []string
+	}
+
+	tests := []testCase{
+		{
+			contains: []string{
+				"<p>first line\nsecond line</p>",
+				`<a href="https://example.com">link</a>`,
+			},
+		},
+		{
+			opts: []ssg.Option{
+				ssg.MarkdownExtensions(ssg.SsgExtensions | parser.HardLineBreak),
+				ssg.MarkdownFlags(ssg.HTMLFlags | html.HrefTargetBlank),
+			},
+			contains: []string{
+				"<p>first line<br>\nsecond line</p>",
+				`<a href="https://example.com" target="_blank">link</a>`,
+			},
+		},
+		{
+			opts: []ssg.Option{
+				ssg.WithMarkdown(ssg.Markdown{
+					Extensions: parser.CommonExtensions,
+					Flags:      html.CommonFlags | html.SkipLinks,
+				}),
+			},
+			contains: []string{
+				`<h1>Title</h1>`,
+			},
+			excludes: []string{
+				`<a href`,
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := &tests[i]
+		_, outputs, err := ssg.Build(src, dst, "TestMarkdownOptions", "https://example.com", nil, tc.opts...)
+		if err != nil {
+			t.Fatalf("[case %d] unexpected error: %v", i+1, err)
+		}
+		if len(outputs) != 1 {
+			t.Fatalf("[case %d] unexpected number of outputs %d", i+1, len(outputs))
+		}
+
+		data := string(outputs[0].Data())
+		for _, s := range tc.contains {
+			if !strings.Contains(data, s) {
+				t.Fatalf("[case %d] missing expected substr '%s' from output:\n%s", i+1, s, data)
+			}
+		}
+		for _, s := range tc.excludes {
+			if strings.Contains(data, s) {
+				t.Fatalf("[case %d] unexpected substr '%s' in output:\n%s", i+1, s, data)
+			}
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package ssg
 
-import "io/fs"
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
 
 // OutputFile is the main output struct for ssg-go.
 //
@@ -12,6 +16,8 @@ type OutputFile struct {
 	originator string
 	data       []byte
 	perm       fs.FileMode
+	noSitemap  bool   // Excluded from sitemap.xml
+	symlink    string // Link target if output is a symlink, see SymlinkCopy
 }
 
 // Outputs is any collection out OutputFile.
@@ -30,8 +36,23 @@ type buildOutput struct {
 	writer      Outputs      // Main outputs
 	files       []string     // Input files read (not ignored)
 	cache       []OutputFile // Cache of main outputs
+
+	collisions  CollisionPolicy
+	originators map[string]string // Originators of outputs, keyed by target
+	cached      map[string]int    // Index of outputs in cache, keyed by target
 }
 
+// CollisionPolicy decides what happens when multiple outputs
+// from a build have the same target, e.g. after a pipeline renames a file
+type CollisionPolicy uint8
+
+const (
+	CollisionError    CollisionPolicy = iota // Fail the build with ErrOutputCollision
+	CollisionLastWins                        // Keep the output added last
+)
+
+var ErrOutputCollision = errors.New("output collision")
+
 func NewOutputsStreaming(c chan<- OutputFile) Outputs {
 	return outputsV1{stream: c}
 }
@@ -42,6 +63,40 @@ func (o outputsV1) Add(outputs ...OutputFile) {
 	}
 }
 
+// add adds outputs from a build, and returns ErrOutputCollision
+// naming both originators if an output target was already added,
+// unless the policy is CollisionLastWins.
+func (b *buildOutput) add(outputs ...OutputFile) error {
+	if b.originators == nil {
+		b.originators = make(map[string]string)
+		b.cached = make(map[string]int)
+	}
+
+	for i := range outputs {
+		o := &outputs[i]
+		prev, ok := b.originators[o.target]
+		if ok && b.collisions != CollisionLastWins {
+			return fmt.Errorf("%w: '%s' and '%s' both output to '%s'", ErrOutputCollision, prev, o.originator, o.target)
+		}
+		b.originators[o.target] = o.originator
+
+		if !b.cacheOutput {
+			continue
+		}
+		if j, ok := b.cached[o.target]; ok {
+			b.cache[j] = *o
+			continue
+		}
+		b.cached[o.target] = len(b.cache)
+		b.cache = append(b.cache, *o)
+	}
+
+	if b.writer != nil {
+		b.writer.Add(outputs...)
+	}
+	return nil
+}
+
 func (b *buildOutput) Add(outputs ...OutputFile) {
 	if b.cacheOutput {
 		b.cache = append(b.cache, outputs...)
@@ -0,0 +1,81 @@
+package ssg
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutputCollision(t *testing.T) {
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{
+		"_header.html": "",
+		"_footer.html": "",
+		"a.md":         "# A",
+		"b.md":         "# B",
+	})
+
+	// Renames b.md to a.md
+	rename := func(path string, data []byte, d fs.DirEntry) (string, []byte, fs.DirEntry, error) {
+		if filepath.Base(path) == "b.md" {
+			path = filepath.Join(filepath.Dir(path), "a.md")
+		}
+		return path, data, d, nil
+	}
+
+	dst := filepath.Join(t.TempDir(), "dst")
+	_, _, err := Build(src, dst, "TestOutputCollision", "https://example.com", nil, WithPipelines(rename))
+	if !errors.Is(err, ErrOutputCollision) {
+		t.Fatalf("unexpected error for collision: %v", err)
+	}
+	for _, originator := range []string{"a.md", "b.md"} {
+		if !strings.Contains(err.Error(), filepath.Join(src, originator)) {
+			t.Fatalf("missing originator '%s' in error: %v", originator, err)
+		}
+	}
+
+	_, outputs, err := Build(src, dst, "TestOutputCollision", "https://example.com", nil, WithPipelines(rename), OnCollision(CollisionLastWins))
+	if err != nil {
+		t.Fatalf("unexpected error with last-wins policy: %v", err)
+	}
+	if len(outputs) != 1 {
+		t.Fatalf("unexpected number of outputs: expected=1, actual=%d", len(outputs))
+	}
+	if o := outputs[0]; string(o.data) != "<h1 id=\"b\">B</h1>\n" || o.originator != filepath.Join(src, "b.md") {
+		t.Fatalf("unexpected output from '%s':\n%s", o.originator, o.data)
+	}
+}
+
+func TestWriteOutLastWins(t *testing.T) {
+	dst := t.TempDir()
+	target := filepath.Join(dst, "index.html")
+
+	for i := 0; i < 20; i++ {
+		stream := make(chan OutputFile)
+		go func() {
+			defer close(stream)
+			for j := 0; j < 50; j++ {
+				stream <- Output(target, fmt.Sprintf("src/%d.md", j), []byte(fmt.Sprintf("%d", j)), 0o644)
+			}
+		}()
+
+		written, err := WriteOut(stream, 8)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(written) != 1 || written[0].originator != "src/49.md" {
+			t.Fatalf("unexpected written outputs: %+v", written)
+		}
+		data, err := os.ReadFile(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "49" {
+			t.Fatalf("unexpected data written: %s", data)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package ssg
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var ErrPermalink = errors.New("bad permalink")
+
+// ErrPermalinkCollision is returned when two pages map to the same permalink.
+//
+// Deprecated: all output collisions are reported as [ErrOutputCollision].
+var ErrPermalinkCollision = ErrOutputCollision
+
+var rePermalinkToken = regexp.MustCompile(`:[A-Za-z_]+`)
+
+// Permalink pattern placeholders, e.g. "/:year/:month/:slug/"
+const (
+	PermalinkYear     = ":year"     // Year of :ssg-date
+	PermalinkMonth    = ":month"    // 2-digit month of :ssg-date
+	PermalinkDay      = ":day"      // 2-digit day of :ssg-date
+	PermalinkSlug     = ":slug"     // Slug of page title, or of filename if untitled
+	PermalinkFilename = ":filename" // Filename without extension
+)
+
+// validatePermalink returns ErrPermalink if pattern has unknown placeholders
+func validatePermalink(pattern string) error {
+	for _, token := range rePermalinkToken.FindAllString(pattern, -1) {
+		switch token {
+		case PermalinkYear, PermalinkMonth, PermalinkDay, PermalinkSlug, PermalinkFilename:
+			continue
+		}
+		return fmt.Errorf("%w: unknown placeholder '%s' in '%s'", ErrPermalink, token, pattern)
+	}
+	return nil
+}
+
+// pageTarget returns output path of page at path with extension ext.
+// rebase is the relative path from output directory to the mirrored directory,
+// or empty if the page is output to its mirrored directory.
+//
+// By default, page foo.md is output to foo.html, or foo/index.html with [PrettyURLs].
+// Non-index pages under directories with permalink pattern are output to
+// the expanded pattern instead.
+func (s *Ssg) pageTarget(
+	path string,
+	ext string,
+	title []byte,
+	page directives,
+) (
+	target string,
+	rebase string,
+	err error,
+) {
+	mirrored, err := mirrorPath(s.Src, s.Dst, path)
+	if err != nil {
+		return "", "", err
+	}
+
+	// foo.md -> foo.html
+	mirrored = ChangeExt(mirrored, ext, ".html")
+	target = mirrored
+
+	isIndex := filepath.Base(mirrored) == "index.html"
+	c := s.configs.choose(path)
+	switch {
+	case isIndex:
+
+	case c.Permalink != nil && *c.Permalink != "":
+		target, err = s.permalink(path, ext, *c.Permalink, title, page)
+		if err != nil {
+			return "", "", err
+		}
+
+	// foo.html -> foo/index.html
+	case s.options.prettyURLs:
+		target, _ = prettyTarget(mirrored)
+		err = prettyCollision(path, ext)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	if target == mirrored {
+		return target, "", nil
+	}
+	rebase, err = filepath.Rel(filepath.Dir(target), filepath.Dir(mirrored))
+	if err != nil {
+		return "", "", err
+	}
+	if rebase == "." {
+		return target, "", nil
+	}
+	return target, filepath.ToSlash(rebase) + "/", nil
+}
+
+// permalink expands permalink pattern for page at path.
+// Patterns starting with "/" are relative to dst, and other patterns
+// are relative to the page's mirrored directory. Patterns ending with "/"
+// or without extension are output to index.html under the expanded path.
+func (s *Ssg) permalink(path, ext, pattern string, title []byte, page directives) (string, error) {
+	stem := strings.TrimSuffix(filepath.Base(path), ext)
+	slug := Slugify(string(title))
+	if slug == "" {
+		slug = Slugify(stem)
+	}
+
+	var date time.Time
+	if strings.Contains(pattern, PermalinkYear) ||
+		strings.Contains(pattern, PermalinkMonth) ||
+		strings.Contains(pattern, PermalinkDay) {
+
+		if page.date == "" {
+			return "", fmt.Errorf("%w: '%s' requires %s", ErrPermalink, pattern, strings.TrimSpace(DirectiveDate))
+		}
+		var err error
+		date, err = ParseDate(page.date)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	link := strings.NewReplacer(
+		PermalinkYear, fmt.Sprintf("%04d", date.Year()),
+		PermalinkMonth, fmt.Sprintf("%02d", date.Month()),
+		PermalinkDay, fmt.Sprintf("%02d", date.Day()),
+		PermalinkSlug, slug,
+		PermalinkFilename, stem,
+	).Replace(pattern)
+
+	base := s.Dst
+	if !strings.HasPrefix(link, "/") {
+		mirrored, err := mirrorPath(s.Src, s.Dst, path)
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Dir(mirrored)
+	}
+
+	target := filepath.Join(base, filepath.FromSlash(link))
+	if strings.HasSuffix(link, "/") || filepath.Ext(target) == "" {
+		target = filepath.Join(target, "index.html")
+	}
+
+	rel, err := filepath.Rel(s.Dst, target)
+	if err != nil {
+		return "", err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: '%s' is outside of dst", ErrPermalink, pattern)
+	}
+	return target, nil
+}
@@ -0,0 +1,84 @@
+package ssg
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestPermalinks(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	writeFiles(t, src, map[string]string{
+		"_header.html":           "",
+		"_footer.html":           "",
+		"index.md":               "# Home",
+		"blog/_ssg.json":         `{"permalink": "/:year/:month/:slug/"}`,
+		"blog/2023-hello.md":     ":ssg-date 2023-03-24\n# Hello, World!\n\n![Cat](cat.png)",
+		"blog/cat.png":           "png",
+		"blog/thai.md":           ":ssg-date 2024-01-02\n# สวัสดี ชาวโลก",
+		"blog/index.md":          "# Blog",
+		"blog/raw/_ssg.json":     `{"permalink": ""}`,
+		"blog/raw/post.md":       "# Raw",
+		"notes/_ssg.json":        `{"permalink": ":filename.html", "index": true}`,
+		"notes/deep/_ssg.json":   `{"permalink": "/notes/:slug.html"}`,
+		"notes/deep/untitled.md": "No title",
+		"notes/a.md":             "# A note",
+	})
+
+	s := New(src, dst, "TestPermalinks", "https://example.com")
+	s.With(Caching(true))
+	_, outputs, err := s.Build(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expecteds := map[string]string{
+		"index.html":                     "<h1 id=\"home\">Home</h1>\n",
+		"blog/index.html":                "<h1 id=\"blog\">Blog</h1>\n",
+		"2023/03/hello-world/index.html": "<h1 id=\"hello-world\">Hello, World!</h1>\n\n<p><img src=\"../../../blog/cat.png\" alt=\"Cat\" /></p>\n",
+		"2024/01/สวัสดี-ชาวโลก/index.html": "<h1 id=\"สว-สด-ชาวโลก\">สวัสดี ชาวโลก</h1>\n",
+		"blog/cat.png":          "png",
+		"blog/raw/post.html":    "<h1 id=\"raw\">Raw</h1>\n",
+		"notes/a.html":          "<h1 id=\"a-note\">A note</h1>\n",
+		"notes/untitled.html":   "<p>No title</p>\n",
+		"notes/index.html":      "<h1>notes</h1>\n<ul class=\"ssg-index\">\n<li><a href=\"deep/\">deep/</a></li>\n<li><a href=\"a.html\">A note</a></li>\n</ul>\n",
+		"notes/deep/index.html": "<h1>deep</h1>\n<ul class=\"ssg-index\">\n<li><a href=\"../untitled.html\">../untitled.html</a></li>\n</ul>\n",
+	}
+
+	if len(outputs) != len(expecteds) {
+		for i := range outputs {
+			t.Logf("output: %s", outputs[i].target)
+		}
+		t.Fatalf("unexpected number of outputs: expected=%d, actual=%d", len(expecteds), len(outputs))
+	}
+	for i := range outputs {
+		o := &outputs[i]
+		rel, err := filepath.Rel(dst, o.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, ok := expecteds[rel]
+		if !ok {
+			t.Fatalf("unexpected output '%s'", rel)
+		}
+		if string(o.data) != expected {
+			t.Logf("expected:\n%s", expected)
+			t.Logf("actual:\n%s", o.data)
+			t.Fatalf("unexpected output data for '%s'", rel)
+		}
+	}
+
+	writeFiles(t, src, map[string]string{"blog/copy.md": ":ssg-date 2023-03-01\n# Hello World"})
+	_, _, err = Build(src, filepath.Join(t.TempDir(), "dst"), "TestPermalinks", "https://example.com", nil)
+	if !errors.Is(err, ErrOutputCollision) {
+		t.Fatalf("unexpected error for colliding permalinks: %v", err)
+	}
+
+	writeFiles(t, src, map[string]string{"blog/copy.md": "# Undated"})
+	_, _, err = Build(src, filepath.Join(t.TempDir(), "dst"), "TestPermalinks", "https://example.com", nil)
+	if !errors.Is(err, ErrPermalink) {
+		t.Fatalf("unexpected error for undated page: %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+package ssg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrSrcNotDir      = errors.New("src is not a directory")
+	ErrDstInSrc       = errors.New("dst is inside src")
+	ErrSrcInDst       = errors.New("src is inside dst")
+	ErrDstNotWritable = errors.New("dst is not writable")
+	ErrForeignDst     = errors.New("dst was not created by ssg")
+)
+
+// preflight validates src and dst before a build, with symlinks resolved.
+// If write is true, dst is also checked for writes.
+//
+// If dst is inside src and [AllowDstInSrc] is enabled,
+// dst is skipped during the build instead.
+//
+// Gemini dst, if any, is checked against src like dst,
+// and must not be the same as or nested with dst.
+func (s *Ssg) preflight(write bool) error {
+	stat, err := os.Stat(s.Src)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSrcNotDir, err)
+	}
+	if !stat.IsDir() {
+		return fmt.Errorf("%w: '%s'", ErrSrcNotDir, s.Src)
+	}
+
+	s.skipDsts = make(Set)
+	dsts := s.dsts()
+	if s.options.gemini != "" {
+		_, err := checkLayout(s.Dst, s.options.gemini)
+		if err != nil {
+			return fmt.Errorf("gemini dst '%s' overlaps dst '%s': %w", s.options.gemini, s.Dst, err)
+		}
+	}
+	for _, dst := range dsts {
+		rel, err := checkLayout(s.Src, dst)
+		switch {
+		case errors.Is(err, ErrDstInSrc) && s.options.allowDstInSrc:
+			s.skipDsts.Insert(filepath.Join(s.Src, rel))
+
+		case err != nil:
+			return err
+		}
+	}
+
+	if !write {
+		return nil
+	}
+	for _, dst := range dsts {
+		err := checkDst(dst)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dsts returns dst and gemini dst, if any
+func (s *Ssg) dsts() []string {
+	if s.options.gemini == "" {
+		return []string{s.Dst}
+	}
+	return []string{s.Dst, s.options.gemini}
+}
+
+// checkLayout returns an error if src and dst, with symlinks resolved,
+// are the same directory or nested in each other.
+// If dst is inside src, rel is the path of dst relative to src.
+func checkLayout(src, dst string) (rel string, err error) {
+	srcReal, err := resolvePath(src)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrSrcNotDir, err)
+	}
+	dstReal, err := resolvePath(dst)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrDstNotWritable, err)
+	}
+
+	switch {
+	case srcReal == dstReal:
+		return "", fmt.Errorf("%w: '%s' and '%s' are both '%s'", ErrSrcIsDst, src, dst, srcReal)
+
+	case isWithin(srcReal, dstReal):
+		rel, err := filepath.Rel(srcReal, dstReal)
+		if err != nil {
+			return "", err
+		}
+		return rel, fmt.Errorf("%w: '%s' is inside '%s', move dst outside of src or use AllowDstInSrc", ErrDstInSrc, dst, src)
+
+	case isWithin(dstReal, srcReal):
+		return "", fmt.Errorf("%w: '%s' is inside '%s', and would be overwritten or removed by builds", ErrSrcInDst, src, dst)
+	}
+
+	return "", nil
+}
+
+// checkDst returns an error if dst cannot be written to,
+// or if dst is an existing non-empty directory without .files,
+// i.e. a directory not generated by ssg.
+func checkDst(dst string) error {
+	entries, err := os.ReadDir(dst)
+	switch {
+	case err == nil:
+		if len(entries) != 0 {
+			_, err := os.Stat(filepath.Join(dst, ".files"))
+			if os.IsNotExist(err) {
+				return fmt.Errorf("%w: '%s' has no .files, remove it or choose another dst", ErrForeignDst, dst)
+			}
+		}
+
+	case os.IsNotExist(err):
+		// dst will be created in its nearest existing ancestor
+		for os.IsNotExist(err) && filepath.Dir(dst) != dst {
+			dst = filepath.Dir(dst)
+			_, err = os.Stat(dst)
+		}
+
+	default:
+		return fmt.Errorf("%w: %w", ErrDstNotWritable, err)
+	}
+
+	f, err := os.CreateTemp(dst, ".ssg-preflight-*")
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrDstNotWritable, err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// claimDst marks dst as generated by ssg with an empty .files,
+// unless dst already has .files from a previous build
+func claimDst(dst string) error {
+	path := filepath.Join(dst, ".files")
+	_, err := os.Stat(path)
+	if !os.IsNotExist(err) {
+		return err
+	}
+	err = os.MkdirAll(dst, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrDstNotWritable, err)
+	}
+	return os.WriteFile(path, nil, 0o644)
+}
+
+// resolvePath returns absolute path with symlinks resolved.
+// Paths that do not exist yet are resolved from their nearest existing ancestor.
+func resolvePath(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	var missing []string
+	for {
+		resolved, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			for i := len(missing) - 1; i >= 0; i-- {
+				resolved = filepath.Join(resolved, missing[i])
+			}
+			return resolved, nil
+		}
+		parent := filepath.Dir(path)
+		if !os.IsNotExist(err) || parent == path {
+			return "", err
+		}
+		missing = append(missing, filepath.Base(path))
+		path = parent
+	}
+}
+
+// isWithin returns whether path is a descendant of dir
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
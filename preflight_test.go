@@ -0,0 +1,82 @@
+package ssg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPreflight(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"src/index.md":         "# Index",
+		"src/public/.files":    "./index.md\n",
+		"src/public/stale.md":  "# Stale",
+		"file.md":              "# File",
+		"foreign/notes.txt":    "notes",
+		"generated/.files":     "",
+		"generated/index.html": "old",
+	})
+	src := filepath.Join(root, "src")
+	link := filepath.Join(root, "link")
+	err := os.Symlink(src, link)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		src      string
+		dst      string
+		expected error
+	}{
+		{name: "missing src", src: filepath.Join(root, "missing"), dst: filepath.Join(root, "dst"), expected: ErrSrcNotDir},
+		{name: "file src", src: filepath.Join(root, "file.md"), dst: filepath.Join(root, "dst"), expected: ErrSrcNotDir},
+		{name: "dst in src", src: src, dst: filepath.Join(src, "public"), expected: ErrDstInSrc},
+		{name: "new dst in src", src: src, dst: filepath.Join(src, "new", "public"), expected: ErrDstInSrc},
+		{name: "src in dst", src: src, dst: root, expected: ErrSrcInDst},
+		{name: "dst is symlinked src", src: src, dst: link, expected: ErrSrcIsDst},
+		{name: "dst in symlinked src", src: src, dst: filepath.Join(link, "public"), expected: ErrDstInSrc},
+		{name: "foreign dst", src: src, dst: filepath.Join(root, "foreign"), expected: ErrForeignDst},
+		{name: "dst in file", src: src, dst: filepath.Join(root, "file.md", "dst"), expected: ErrDstNotWritable},
+		{name: "generated dst", src: src, dst: filepath.Join(root, "generated"), expected: nil},
+		{name: "new dst", src: src, dst: filepath.Join(root, "new", "dst"), expected: nil},
+	}
+
+	for _, tc := range tests {
+		err := Generate(tc.src, tc.dst, "TestPreflight", "https://example.com")
+		if !errors.Is(err, tc.expected) {
+			t.Fatalf("[%s] unexpected error: expected=%v, actual=%v", tc.name, tc.expected, err)
+		}
+	}
+
+	// dst inside src is skipped with AllowDstInSrc
+	dst := filepath.Join(src, "public")
+	_, outputs, err := Build(src, dst, "TestPreflight", "https://example.com", nil, AllowDstInSrc(true))
+	if err != nil {
+		t.Fatalf("unexpected error with AllowDstInSrc: %v", err)
+	}
+	if len(outputs) != 1 || outputs[0].target != filepath.Join(dst, "index.html") {
+		t.Fatalf("unexpected outputs with AllowDstInSrc: %+v", outputs)
+	}
+}
+
+func TestPreflightDstNotWritable(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can write to read-only directories")
+	}
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"src/index.md": "# Index"})
+	readOnly := filepath.Join(root, "readonly")
+	err := os.Mkdir(readOnly, 0o555)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chmod(readOnly, 0o755) })
+
+	err = Generate(filepath.Join(root, "src"), filepath.Join(readOnly, "dst"), "TestPreflightDstNotWritable", "https://example.com")
+	if !errors.Is(err, ErrDstNotWritable) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package ssg
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var ErrPrettyURLCollision = errors.New("pretty url collides with directory")
+
+var reURLAttr = regexp.MustCompile(`(\s(?:href|src)=)(?:"([^"]*)"|'([^']*)')`)
+
+// prettyTarget returns pretty target for page output target,
+// e.g. foo.html -> foo/index.html. Index pages are unchanged.
+func prettyTarget(target string) (string, bool) {
+	if filepath.Base(target) == "index.html" {
+		return target, false
+	}
+	return filepath.Join(strings.TrimSuffix(target, ".html"), "index.html"), true
+}
+
+// prettyCollision returns ErrPrettyURLCollision if pretty output of page at path
+// would be written into an existing directory in src, e.g. foo/ for foo.md
+func prettyCollision(path string, ext string) error {
+	dir := strings.TrimSuffix(path, ext)
+	stat, err := os.Stat(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if stat.IsDir() {
+		return fmt.Errorf("%w: %s and %s", ErrPrettyURLCollision, path, dir)
+	}
+	return nil
+}
+
+// RebaseURLs prefixes relative URLs in href and src attributes of html with prefix,
+// e.g. with prefix "../", src="foo.png" becomes src="../foo.png".
+//
+// Absolute URLs, root-relative URLs and URLs with only fragments
+// or queries are left unchanged.
+func RebaseURLs(html []byte, prefix string) []byte {
+	return reURLAttr.ReplaceAllFunc(html, func(match []byte) []byte {
+		groups := reURLAttr.FindSubmatch(match)
+		attr, quote, u := groups[1], "\"", groups[2]
+		if groups[3] != nil {
+			quote, u = "'", groups[3]
+		}
+		if !isRelativeURL(string(u)) {
+			return match
+		}
+		rebased := make([]byte, 0, len(match)+len(prefix))
+		rebased = append(rebased, attr...)
+		rebased = append(rebased, quote...)
+		rebased = append(rebased, prefix...)
+		rebased = append(rebased, u...)
+		return append(rebased, quote...)
+	})
+}
+
+// prettyLinks rewrites relative links in html of page at path
+// to pages whose outputs are prettified, e.g. href="bar.html" to href="bar/".
+// Links to HTML files in src, which are copied as is, are left unchanged.
+func (s *Ssg) prettyLinks(path string, html []byte) []byte {
+	return reURLAttr.ReplaceAllFunc(html, func(match []byte) []byte {
+		groups := reURLAttr.FindSubmatch(match)
+		attr, quote, u := groups[1], "\"", string(groups[2])
+		if groups[3] != nil {
+			quote, u = "'", string(groups[3])
+		}
+		if !isRelativeURL(u) {
+			return match
+		}
+		link, rest := u, ""
+		if i := strings.IndexAny(u, "?#"); i != -1 {
+			link, rest = u[:i], u[i:]
+		}
+		if !strings.HasSuffix(link, ".html") || filepath.Base(link) == "index.html" {
+			return match
+		}
+		if !s.prettified(filepath.Join(filepath.Dir(path), filepath.FromSlash(link))) {
+			return match
+		}
+		pretty := strings.TrimSuffix(link, ".html") + "/" + rest
+		return []byte(string(attr) + quote + pretty + quote)
+	})
+}
+
+// prettified returns whether HTML output at mirrored path target in src
+// is built from a convertible page, and hence prettified
+func (s *Ssg) prettified(target string) bool {
+	if _, err := os.Stat(target); err == nil {
+		return false
+	}
+	stem := strings.TrimSuffix(target, ".html")
+	exts := []string{".md", ExtFragment}
+	for ext := range s.options.converters {
+		exts = append(exts, ext)
+	}
+	for _, ext := range exts {
+		path := stem + ext
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if _, converter := s.options.converter(path, s.markdown(path)); converter != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func isRelativeURL(u string) bool {
+	if u == "" || strings.HasPrefix(u, "/") || strings.HasPrefix(u, "#") || strings.HasPrefix(u, "?") {
+		return false
+	}
+	parsed, err := url.Parse(u)
+	return err == nil && parsed.Scheme == ""
+}
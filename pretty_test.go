@@ -0,0 +1,139 @@
+package ssg
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRebaseURLs(t *testing.T) {
+	tests := map[string]string{
+		`<img src="foo.png">`:                      `<img src="../foo.png">`,
+		`<a href='bar/baz.html'>`:                  `<a href='../bar/baz.html'>`,
+		`<a href="/root.html">`:                    `<a href="/root.html">`,
+		`<a href="https://example.com">`:           `<a href="https://example.com">`,
+		`<a href="mailto:john@example.com">`:       `<a href="mailto:john@example.com">`,
+		`<a href="#top">`:                          `<a href="#top">`,
+		`<a href="">`:                              `<a href="">`,
+		`<a data-href="foo.html" href="foo.html">`: `<a data-href="foo.html" href="../foo.html">`,
+	}
+	for input, expected := range tests {
+		if actual := string(RebaseURLs([]byte(input), "../")); actual != expected {
+			t.Fatalf("unexpected rebased '%s': expected='%s', actual='%s'", input, expected, actual)
+		}
+	}
+}
+
+func TestPrettyURLs(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	writeFiles(t, src, map[string]string{
+		"_header.html":        "<link href=\"style.css\">\n",
+		"_footer.html":        "",
+		"index.md":            "# Home",
+		"style.css":           "body {}",
+		"blog/post.md":        "# Post\n\n![Cat](cat.png)",
+		"blog/cat.png":        "png",
+		"blog/preferred.md":   "# Preferred",
+		"blog/preferred.html": "<h1>Preferred</h1>",
+	})
+
+	s := New(src, dst, "TestPrettyURLs", "https://example.com")
+	s.With(Caching(true), PrettyURLs(true), AutoIndex(true))
+	_, outputs, err := s.Build(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expecteds := map[string]string{
+		"index.html":           "<link href=\"style.css\">\n<h1 id=\"home\">Home</h1>\n",
+		"blog/post/index.html": "<link href=\"../style.css\">\n<h1 id=\"post\">Post</h1>\n\n<p><img src=\"../cat.png\" alt=\"Cat\" /></p>\n",
+		"blog/index.html":      "<link href=\"style.css\">\n<h1>blog</h1>\n<ul class=\"ssg-index\">\n<li><a href=\"post/\">Post</a></li>\n<li><a href=\"preferred.html\">Preferred</a></li>\n</ul>\n",
+		"blog/preferred.html":  "<h1>Preferred</h1>",
+	}
+	found := make(map[string]bool)
+	for i := range outputs {
+		o := &outputs[i]
+		rel, err := filepath.Rel(dst, o.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, ok := expecteds[rel]
+		if !ok {
+			continue
+		}
+		found[rel] = true
+		if string(o.data) != expected {
+			t.Logf("expected:\n%s", expected)
+			t.Logf("actual:\n%s", o.data)
+			t.Fatalf("unexpected output data for '%s'", rel)
+		}
+	}
+	if len(found) != len(expecteds) {
+		t.Fatalf("unexpected number of outputs: expected=%d, actual=%d", len(expecteds), len(found))
+	}
+
+	sitemap, err := Sitemap(dst, "https://example.com", time.Now(), outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sitemap, "<loc>https://example.com/blog/post/") {
+		t.Fatalf("missing pretty url in sitemap:\n%s", sitemap)
+	}
+
+	writeFiles(t, src, map[string]string{"blog/post/attachment.txt": "collision"})
+	_, _, err = Build(src, filepath.Join(t.TempDir(), "dst"), "TestPrettyURLs", "https://example.com", nil, PrettyURLs(true))
+	if !errors.Is(err, ErrPrettyURLCollision) {
+		t.Fatalf("unexpected error for collision: %v", err)
+	}
+}
+
+func TestPrettyLinks(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	writeFiles(t, src, map[string]string{
+		"_header.html":         "",
+		"_footer.html":         "",
+		"index.md":             "[About](about.html)",
+		"about.md":             "[Home](index.html) [Bar](bar.html#top) [Raw](raw.html) [Missing](missing.html) [Blog](blog/post.html?q=1)",
+		"bar.md":               "# Bar",
+		"raw.html":             "<h1>Raw</h1>",
+		"blog/post.md":         "[About](../about.html)",
+		"blog/index.frag.html": "<a href=\"post.html\">Post</a>",
+	})
+
+	_, outputs, err := Build(src, dst, "TestPrettyLinks", "https://example.com", nil, PrettyURLs(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expecteds := map[string]string{
+		"index.html":           `<p><a href="about/">About</a></p>` + "\n",
+		"about/index.html":     `<p><a href="../index.html">Home</a> <a href="../bar/#top">Bar</a> <a href="../raw.html">Raw</a> <a href="../missing.html">Missing</a> <a href="../blog/post/?q=1">Blog</a></p>` + "\n",
+		"bar/index.html":       `<h1 id="bar">Bar</h1>` + "\n",
+		"raw.html":             "<h1>Raw</h1>",
+		"blog/post/index.html": `<p><a href="../../about/">About</a></p>` + "\n",
+		"blog/index.html":      `<a href="post/">Post</a>`,
+	}
+	if len(outputs) != len(expecteds) {
+		t.Fatalf("unexpected number of outputs: expected=%d, actual=%d", len(expecteds), len(outputs))
+	}
+	for i := range outputs {
+		o := &outputs[i]
+		rel, err := filepath.Rel(dst, o.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, ok := expecteds[rel]
+		if !ok {
+			t.Fatalf("unexpected output '%s'", rel)
+		}
+		if string(o.data) != expected {
+			t.Fatalf("unexpected data for '%s':\nexpected=%s\nactual=%s", rel, expected, o.data)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package ssg
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// MarkerRedirects is the redirect rules file at the root of src.
+//
+// Each line is a rule "from to [status]", e.g. "/old/ /new/ 301",
+// where from is a path under the site root, and to is either
+// a path under the site root or an absolute URL.
+// Empty lines and lines starting with "#" are ignored.
+const MarkerRedirects = "_redirects"
+
+// DirectiveAliases sets comma-separated old paths of a page,
+// e.g. ":ssg-aliases /old/post.html, /older/post/"
+const DirectiveAliases = ":ssg-aliases "
+
+// RedirectFormat is a server configuration format for redirect rules,
+// emitted in addition to the HTML redirect stubs
+type RedirectFormat uint8
+
+const (
+	RedirectFormatNetlify RedirectFormat = iota // ${dst}/_redirects
+	RedirectFormatNginx                         // ${dst}/_redirects.nginx.conf
+)
+
+const (
+	RedirectsNetlify = "_redirects"
+	RedirectsNginx   = "_redirects.nginx.conf"
+)
+
+var ErrBadRedirect = errors.New("bad redirect")
+
+// redirectUnsafe are characters not allowed in redirect rules
+const redirectUnsafe = " \t\r\n;{}\"'"
+
+// redirect is a redirect rule from _redirects or a page alias
+type redirect struct {
+	from   string
+	to     string
+	status int
+	source string
+}
+
+// parseRedirects parses redirect rules in the format of [MarkerRedirects]
+func parseRedirects(data []byte) ([]redirect, error) {
+	var redirects []redirect
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 && len(fields) != 3 {
+			return nil, fmt.Errorf("%w: line %d: expecting 'from to [status]'", ErrBadRedirect, n)
+		}
+		r := redirect{from: fields[0], to: fields[1], status: 301}
+		if len(fields) == 3 {
+			status, err := strconv.Atoi(fields[2])
+			if err != nil || status < 300 || status > 399 {
+				return nil, fmt.Errorf("%w: line %d: bad status '%s'", ErrBadRedirect, n, fields[2])
+			}
+			r.status = status
+		}
+		err := r.validate()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		redirects = append(redirects, r)
+	}
+	return redirects, s.Err()
+}
+
+func (r redirect) validate() error {
+	if !strings.HasPrefix(r.from, "/") {
+		return fmt.Errorf("%w: from '%s' is not under the site root", ErrBadRedirect, r.from)
+	}
+	for _, segment := range strings.Split(r.from, "/") {
+		if segment == ".." {
+			return fmt.Errorf("%w: from '%s' contains '..'", ErrBadRedirect, r.from)
+		}
+	}
+	if !strings.HasPrefix(r.to, "/") && !strings.Contains(r.to, "://") {
+		return fmt.Errorf("%w: to '%s' is neither a path under the site root nor an absolute url", ErrBadRedirect, r.to)
+	}
+	// Rules are written verbatim to server configurations, e.g. nginx
+	for _, v := range []string{r.from, r.to} {
+		if strings.ContainsAny(v, redirectUnsafe) {
+			return fmt.Errorf("%w: '%s' contains whitespace or one of '%s'", ErrBadRedirect, v, strings.TrimSpace(redirectUnsafe))
+		}
+	}
+	return nil
+}
+
+// collectRedirects reads redirect rules from MarkerRedirects
+func (s *Ssg) collectRedirects() error {
+	s.redirects = nil
+	path := filepath.Join(s.Src, MarkerRedirects)
+	data, err := ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	redirects, err := parseRedirects(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for i := range redirects {
+		redirects[i].source = path
+	}
+	s.redirects = redirects
+	return nil
+}
+
+// addAliases adds redirects from page aliases to the page's output target
+func (s *Ssg) addAliases(target string, path string, page directives) error {
+	if len(page.aliases) == 0 {
+		return nil
+	}
+	rel, err := filepath.Rel(s.Dst, target)
+	if err != nil {
+		return err
+	}
+	to := "/" + filepath.ToSlash(rel)
+	to = strings.TrimSuffix(to, "index.html")
+
+	for _, alias := range page.aliases {
+		r := redirect{from: alias, to: to, status: 301, source: path}
+		err := r.validate()
+		if err != nil {
+			return err
+		}
+		s.redirects = append(s.redirects, r)
+	}
+	return nil
+}
+
+// redirectOutputs returns HTML redirect stubs for the collected redirects,
+// and redirect rules in formats set with [Redirects]
+func (s *Ssg) redirectOutputs() ([]OutputFile, error) {
+	if len(s.redirects) == 0 {
+		return nil, nil
+	}
+
+	url := strings.TrimSuffix(s.URL, "/")
+	outputs := make([]OutputFile, 0, len(s.redirects)+len(s.options.redirectFormats))
+	for _, r := range s.redirects {
+		target := filepath.Join(s.Dst, filepath.FromSlash(r.from))
+		if strings.HasSuffix(r.from, "/") || filepath.Ext(target) == "" {
+			target = filepath.Join(target, "index.html")
+		}
+		canonical := r.to
+		if strings.HasPrefix(r.to, "/") {
+			canonical = url + r.to
+		}
+		output := Output(target, r.source, redirectStub(r.to, canonical), 0o644)
+		output.noSitemap = true
+		outputs = append(outputs, output)
+	}
+
+	for _, format := range s.options.redirectFormats {
+		buf := bytes.NewBuffer(nil)
+		name := RedirectsNetlify
+		switch format {
+		case RedirectFormatNetlify:
+			for _, r := range s.redirects {
+				Fprintf(buf, "%s %s %d\n", r.from, r.to, r.status)
+			}
+		case RedirectFormatNginx:
+			name = RedirectsNginx
+			for _, r := range s.redirects {
+				Fprintf(buf, "location = %s { return %d %s; }\n", r.from, r.status, r.to)
+			}
+		default:
+			return nil, fmt.Errorf("unknown redirect format %d", format)
+		}
+		output := Output(filepath.Join(s.Dst, name), "", buf.Bytes(), 0o644)
+		output.noSitemap = true
+		outputs = append(outputs, output)
+	}
+
+	return outputs, nil
+}
+
+// redirectStub returns HTML page redirecting to url
+func redirectStub(to string, canonical string) []byte {
+	to, canonical = html.EscapeString(to), html.EscapeString(canonical)
+	buf := bytes.NewBuffer(nil)
+	Fprintf(buf, `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<title>Redirecting to %s</title>
+<link rel="canonical" href="%s">
+<meta http-equiv="refresh" content="0; url=%s">
+</head>
+<body>
+<p>Redirecting to <a href="%s">%s</a></p>
+</body>
+</html>
+`, to, canonical, to, to, to)
+	return buf.Bytes()
+}
@@ -0,0 +1,145 @@
+package ssg
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRedirects(t *testing.T) {
+	redirects, err := parseRedirects([]byte(`
+# Moved blog
+/old/ /blog/
+/old.html https://example.org/new 302
+/v1..2/ /v2/
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expecteds := []redirect{
+		{from: "/old/", to: "/blog/", status: 301},
+		{from: "/old.html", to: "https://example.org/new", status: 302},
+		{from: "/v1..2/", to: "/v2/", status: 301},
+	}
+	if len(redirects) != len(expecteds) {
+		t.Fatalf("unexpected number of redirects: expected=%d, actual=%d", len(expecteds), len(redirects))
+	}
+	for i := range expecteds {
+		if redirects[i] != expecteds[i] {
+			t.Fatalf("unexpected redirect %d: expected=%+v, actual=%+v", i, expecteds[i], redirects[i])
+		}
+	}
+
+	for _, bad := range []string{
+		"/only-from",
+		"old /new",
+		"/old new",
+		"/old /new 200",
+		"/../old /new",
+		"/old /new 301 extra",
+		"/old/.. /new",
+		"/old;return /new",
+		"/old /new;}",
+		"/old{ /new",
+	} {
+		_, err := parseRedirects([]byte(bad))
+		if !errors.Is(err, ErrBadRedirect) {
+			t.Fatalf("unexpected error for bad redirect '%s': %v", bad, err)
+		}
+	}
+}
+
+func TestRedirects(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	writeFiles(t, src, map[string]string{
+		"_header.html":    "",
+		"_footer.html":    "",
+		"_redirects":      "/old/ /blog/\n/gone.html https://example.org/ 302\n",
+		"index.md":        "# Home",
+		"blog/index.md":   "# Blog",
+		"blog/post.md":    ":ssg-aliases /2023/post.html, /posts/post/\n# Post",
+		"blog/_redirects": "not a root redirects file",
+	})
+
+	s := New(src, dst, "TestRedirects", "https://example.com/")
+	s.With(Caching(true), Redirects(RedirectFormatNetlify, RedirectFormatNginx))
+	_, outputs, err := s.Build(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expecteds := map[string]string{
+		"index.html":            "<h1 id=\"home\">Home</h1>\n",
+		"blog/index.html":       "<h1 id=\"blog\">Blog</h1>\n",
+		"blog/post.html":        "<h1 id=\"post\">Post</h1>\n",
+		"blog/_redirects":       "not a root redirects file",
+		"old/index.html":        string(redirectStub("/blog/", "https://example.com/blog/")),
+		"gone.html":             string(redirectStub("https://example.org/", "https://example.org/")),
+		"2023/post.html":        string(redirectStub("/blog/post.html", "https://example.com/blog/post.html")),
+		"posts/post/index.html": string(redirectStub("/blog/post.html", "https://example.com/blog/post.html")),
+		RedirectsNetlify:        "/old/ /blog/ 301\n/gone.html https://example.org/ 302\n/2023/post.html /blog/post.html 301\n/posts/post/ /blog/post.html 301\n",
+		RedirectsNginx:          "location = /old/ { return 301 /blog/; }\nlocation = /gone.html { return 302 https://example.org/; }\nlocation = /2023/post.html { return 301 /blog/post.html; }\nlocation = /posts/post/ { return 301 /blog/post.html; }\n",
+	}
+
+	if len(outputs) != len(expecteds) {
+		for i := range outputs {
+			t.Logf("output: %s", outputs[i].target)
+		}
+		t.Fatalf("unexpected number of outputs: expected=%d, actual=%d", len(expecteds), len(outputs))
+	}
+	for i := range outputs {
+		o := &outputs[i]
+		rel, err := filepath.Rel(dst, o.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, ok := expecteds[rel]
+		if !ok {
+			t.Fatalf("unexpected output '%s'", rel)
+		}
+		if string(o.data) != expected {
+			t.Logf("expected:\n%s", expected)
+			t.Logf("actual:\n%s", o.data)
+			t.Fatalf("unexpected output data for '%s'", rel)
+		}
+	}
+
+	stub := string(redirectStub("/blog/", "https://example.com/blog/"))
+	for _, s := range []string{
+		`<link rel="canonical" href="https://example.com/blog/">`,
+		`<meta http-equiv="refresh" content="0; url=/blog/">`,
+	} {
+		if !strings.Contains(stub, s) {
+			t.Fatalf("missing '%s' in stub:\n%s", s, stub)
+		}
+	}
+
+	sitemap, err := Sitemap(dst, "https://example.com", time.Now(), outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"old/", "gone.html", "2023/", "posts/", RedirectsNetlify + ">", RedirectsNginx} {
+		if strings.Contains(sitemap, "<loc>https://example.com/"+path) {
+			t.Fatalf("unexpected '%s' in sitemap:\n%s", path, sitemap)
+		}
+	}
+
+	writeFiles(t, src, map[string]string{"blog/post.md": ":ssg-aliases /my post/\n# Post"})
+	_, _, err = Build(src, filepath.Join(t.TempDir(), "dst"), "TestRedirects", "https://example.com", nil)
+	if !errors.Is(err, ErrBadRedirect) {
+		t.Fatalf("unexpected error for alias with space: %v", err)
+	}
+
+	writeFiles(t, src, map[string]string{
+		"_redirects":   "/blog/post.html /elsewhere/\n",
+		"blog/post.md": "# Post",
+	})
+	_, _, err = Build(src, filepath.Join(t.TempDir(), "dst"), "TestRedirects", "https://example.com", nil)
+	if !errors.Is(err, ErrOutputCollision) {
+		t.Fatalf("unexpected error for redirect over page: %v", err)
+	}
+}
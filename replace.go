@@ -0,0 +1,200 @@
+package ssg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+const (
+	// ReplaceUnknownWarn prints a warning for unknown placeholder keys
+	ReplaceUnknownWarn ReplaceUnknown = iota
+	// ReplaceUnknownIgnore silently leaves unknown placeholders unchanged
+	ReplaceUnknownIgnore
+	// ReplaceUnknownError fails the build on unknown placeholder keys
+	ReplaceUnknownError
+)
+
+// rePlaceholder matches ${{ key }} placeholders.
+// Placeholders escaped with another $, i.e. $${{ key }}, are left as ${{ key }}.
+var rePlaceholder = regexp.MustCompile(`(\$?)\$\{\{\s*([^}]*?)\s*\}\}`)
+
+var (
+	ErrUnknownReplacement = errors.New("unknown replacement key")
+	ErrBadReplacement     = errors.New("bad replacement")
+)
+
+type (
+	// Replacements maps placeholder keys to their replacements
+	Replacements map[string]Replacement
+
+	// Replacement describes the replacement text for a placeholder key.
+	//
+	// Its value is taken from exactly one of Text, Env (environment variable)
+	// or File (file content with trailing newline trimmed).
+	//
+	// In JSON, it can also be a string, which is used as Text.
+	Replacement struct {
+		Text string `json:"text"`
+		Env  string `json:"env"`
+		File string `json:"file"`
+
+		// Count is the maximum number of replacements per file.
+		// 0 means all occurrences are replaced.
+		Count int `json:"count"`
+	}
+
+	// ReplaceUnknown is the policy for placeholders with unknown keys
+	ReplaceUnknown uint8
+)
+
+// ReplaceHook returns a [Hook] that replaces ${{ key }} placeholders
+// with the replacement values in r, up to each key's Count per file.
+//
+// Values from environment variables and files are resolved once,
+// when ReplaceHook is called.
+func ReplaceHook(r Replacements, unknown ReplaceUnknown) (Hook, error) {
+	values, err := r.values()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBadReplacement, err)
+	}
+
+	return func(path string, data []byte) ([]byte, error) {
+		if !bytes.Contains(data, []byte("${{")) {
+			return data, nil
+		}
+
+		var errs []error
+		counts := make(map[string]int)
+		replaced := rePlaceholder.ReplaceAllFunc(data, func(match []byte) []byte {
+			sub := rePlaceholder.FindSubmatch(match)
+			escaped, key := len(sub[1]) != 0, string(sub[2])
+			if escaped {
+				return match[1:]
+			}
+
+			replacement, ok := r[key]
+			if !ok {
+				switch unknown {
+				case ReplaceUnknownWarn:
+					Fprintf(os.Stderr, "[ssg-go] warning: unknown replacement key '%s' in %s\n", key, path)
+				case ReplaceUnknownError:
+					errs = append(errs, fmt.Errorf("%w '%s' in %s", ErrUnknownReplacement, key, path))
+				}
+				return match
+			}
+			if replacement.Count > 0 && counts[key] >= replacement.Count {
+				return match
+			}
+
+			counts[key]++
+			return []byte(values[key])
+		})
+
+		if len(errs) != 0 {
+			return nil, errors.Join(errs...)
+		}
+		return replaced, nil
+	}, nil
+}
+
+// WithReplacements returns an option that prepends replacement hook
+// to Ssg's hooks, so that the other hooks see the replaced data.
+// If the replacement values cannot be resolved, Build and Generate return the error.
+func WithReplacements(r Replacements, unknown ReplaceUnknown) Option {
+	hook, err := ReplaceHook(r, unknown)
+	if err != nil {
+		return func(s *Ssg) { s.errInit = errors.Join(s.errInit, err) }
+	}
+	return PrependHooks(hook)
+}
+
+// Validate validates r
+func (r Replacement) Validate() error {
+	sources := 0
+	for _, s := range []string{r.Env, r.File} {
+		if s != "" {
+			sources++
+		}
+	}
+	if sources > 1 || (sources == 1 && r.Text != "") {
+		return errors.New("replacement must have only one of text, env or file")
+	}
+	if r.Count < 0 {
+		return fmt.Errorf("negative replacement count %d", r.Count)
+	}
+	return nil
+}
+
+// Value returns the replacement text of r
+func (r Replacement) Value() (string, error) {
+	switch {
+	case r.Env != "":
+		value, ok := os.LookupEnv(r.Env)
+		if !ok {
+			return "", fmt.Errorf("undefined environment variable '%s'", r.Env)
+		}
+		return value, nil
+
+	case r.File != "":
+		data, err := ReadFile(r.File)
+		if err != nil {
+			return "", err
+		}
+		return string(bytes.TrimSuffix(data, []byte{'\n'})), nil
+	}
+
+	return r.Text, nil
+}
+
+func (r *Replacement) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) != 0 && data[0] == '"' {
+		*r = Replacement{}
+		return json.Unmarshal(data, &r.Text)
+	}
+
+	type replacement Replacement // Avoid recursion
+	var v replacement
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&v)
+	if err != nil {
+		return fmt.Errorf("expecting replacement string or object with 'text', 'env', 'file' and 'count': %w", err)
+	}
+	*r = Replacement(v)
+	return nil
+}
+
+// Validate validates all replacements in r
+func (r Replacements) Validate() error {
+	for _, key := range sortedKeys(r) {
+		if key == "" {
+			return errors.New("empty replacement key")
+		}
+		err := r[key].Validate()
+		if err != nil {
+			return fmt.Errorf("replacement '%s': %w", key, err)
+		}
+	}
+	return nil
+}
+
+func (r Replacements) values() (map[string]string, error) {
+	err := r.Validate()
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(r))
+	for key, replacement := range r {
+		value, err := replacement.Value()
+		if err != nil {
+			return nil, fmt.Errorf("replacement '%s': %w", key, err)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
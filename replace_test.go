@@ -0,0 +1,147 @@
+package ssg_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/soyart/ssg-go"
+)
+
+func TestReplaceHook(t *testing.T) {
+	path := "./testdata/johndoe.com/src/testreplace/testreplace1.md"
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hook, err := ssg.ReplaceHook(ssg.Replacements{
+		"replace-me-0": {Text: "replaced-text-0"},
+		"replace-me-1": {Text: "replaced-text-1", Count: 3},
+	}, ssg.ReplaceUnknownIgnore)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	replaced, err := hook(path, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := string(replaced)
+	if strings.Contains(output, "${{ replace-me-0 }}") {
+		t.Fatalf("unexpected placeholder replace-me-0 left in output:\n%s", output)
+	}
+	// 4 placeholders and 2 mentions in the text
+	if c := strings.Count(output, "replaced-text-0"); c != 6 {
+		t.Fatalf("unexpected count of replaced-text-0: %d", c)
+	}
+	if c := strings.Count(output, "replaced-text-1"); c != 3 {
+		t.Fatalf("unexpected count of replaced-text-1: %d", c)
+	}
+	if !strings.Contains(output, "The 4th change: ${{ replace-me-1 }}") {
+		t.Fatalf("unexpected replacement beyond count:\n%s", output)
+	}
+}
+
+func TestReplaceHookValues(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "value.txt")
+	err := os.WriteFile(file, []byte("from-file\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SSG_TEST_REPLACE", "from-env")
+
+	hook, err := ssg.ReplaceHook(ssg.Replacements{
+		"text": {Text: "from-text"},
+		"env":  {Env: "SSG_TEST_REPLACE"},
+		"file": {File: file},
+	}, ssg.ReplaceUnknownError)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	type testCase struct {
+		input    string
+		expected string
+		err      error
+	}
+
+	tests := []testCase{
+		{
+			input:    "${{ text }} ${{env}} ${{  file  }}",
+			expected: "from-text from-env from-file",
+		},
+		{
+			input:    "$${{ text }} $${{ unknown }} ${{ text }}",
+			expected: "${{ text }} ${{ unknown }} from-text",
+		},
+		{
+			input:    "no placeholders ${{",
+			expected: "no placeholders ${{",
+		},
+		{
+			input: "${{ text }} ${{ unknown }}",
+			err:   ssg.ErrUnknownReplacement,
+		},
+	}
+
+	for i := range tests {
+		tc := &tests[i]
+		actual, err := hook("test.md", []byte(tc.input))
+		if tc.err != nil {
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("[case %d] unexpected error %v, expecting %v", i+1, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("[case %d] unexpected error: %v", i+1, err)
+		}
+		if string(actual) != tc.expected {
+			t.Fatalf("[case %d] unexpected output '%s', expecting '%s'", i+1, actual, tc.expected)
+		}
+	}
+
+	invalids := []ssg.Replacements{
+		{"": {Text: "empty key"}},
+		{"foo": {Text: "foo", Env: "FOO"}},
+		{"foo": {Env: "FOO", File: "foo.txt"}},
+		{"foo": {Text: "foo", Count: -1}},
+		{"foo": {Env: "SSG_TEST_UNDEFINED_ENV"}},
+		{"foo": {File: filepath.Join(t.TempDir(), "missing")}},
+	}
+	for i := range invalids {
+		_, err := ssg.ReplaceHook(invalids[i], ssg.ReplaceUnknownWarn)
+		if err == nil {
+			t.Fatalf("[invalid case %d] unexpected nil error", i+1)
+		}
+	}
+}
+
+func TestManifestReplaces(t *testing.T) {
+	m, err := ssg.ParseManifest("./testdata/manifest.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	replaces := m["johndoe.com"].Replaces
+	expected := ssg.Replacements{
+		"replace-me-0": {Text: "replaced-text-0"},
+		"replace-me-1": {Text: "replaced-text-1", Count: 3},
+	}
+	if len(replaces) != len(expected) {
+		t.Fatalf("unexpected replaces %+v", replaces)
+	}
+	for k, v := range expected {
+		if replaces[k] != v {
+			t.Fatalf("unexpected replacement for '%s': %+v", k, replaces[k])
+		}
+	}
+
+	_, err = ssg.NewManifest([]byte(`{"foo": {"src": "src", "dst": "dst", "replaces": {"a": {"txt": "b"}}}}`))
+	if err == nil {
+		t.Fatalf("unexpected nil error for bad replacement")
+	}
+}
@@ -0,0 +1,79 @@
+package ssg
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Slots are cascading partials substituted into placeholders
+// in headers and footers, e.g. _nav.md replaces {{nav}}.
+// Like _header.html, a slot is chosen from the nearest ancestor directory.
+//
+// A directory can have either the Markdown or the HTML version of a slot.
+const (
+	MarkerNavMarkdown     = "_nav.md"
+	MarkerNavHTML         = "_nav.html"
+	MarkerSidebarMarkdown = "_sidebar.md"
+	MarkerSidebarHTML     = "_sidebar.html"
+
+	PlaceholderNav     = "{{nav}}"
+	PlaceholderSidebar = "{{sidebar}}"
+)
+
+// slot is a rendered slot partial
+type slot struct {
+	html   []byte
+	source string
+}
+
+// isSlot returns whether base is a slot marker
+func isSlot(base string) bool {
+	switch base {
+	case MarkerNavMarkdown, MarkerNavHTML, MarkerSidebarMarkdown, MarkerSidebarHTML:
+		return true
+	}
+	return false
+}
+
+// collectSlot reads and renders slot at path for directory dir.
+// Markdown slots are converted with the directory's Markdown dialect,
+// so collectSlot must be called after the directory's _ssg.json is collected.
+func (s *Ssg) collectSlot(dir string, path string) error {
+	data, err := ReadFile(path)
+	if err != nil {
+		return err
+	}
+	data, err = Include(s.Src, path, data)
+	if err != nil {
+		return err
+	}
+
+	base := filepath.Base(path)
+	if filepath.Ext(base) == ".md" {
+		data = s.markdown(path).ToHTML(data)
+	}
+
+	slots := &s.navs
+	if strings.HasPrefix(base, "_sidebar.") {
+		slots = &s.sidebars
+	}
+	err = slots.add(dir, slot{html: data, source: path})
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// fillSlots replaces slot placeholders in template text
+// with slots chosen for path. Placeholders without slots are removed.
+func (s *Ssg) fillSlots(path string, text []byte) []byte {
+	if bytes.Contains(text, []byte(PlaceholderNav)) {
+		text = bytes.ReplaceAll(text, []byte(PlaceholderNav), s.navs.choose(path).html)
+	}
+	if bytes.Contains(text, []byte(PlaceholderSidebar)) {
+		text = bytes.ReplaceAll(text, []byte(PlaceholderSidebar), s.sidebars.choose(path).html)
+	}
+	return text
+}
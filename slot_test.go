@@ -0,0 +1,79 @@
+package ssg
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSlots(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	writeFiles(t, src, map[string]string{
+		"_header.html":          "<nav>{{nav}}</nav>\n",
+		"_footer.html":          "<aside>{{sidebar}}</aside>\n",
+		"_nav.md":               "[Home](/)",
+		"index.md":              "# Home",
+		"blog/_nav.html":        "<a href=\"/blog\">Blog</a>",
+		"blog/_sidebar.md":      "*Archive*",
+		"blog/index.md":         "# Blog",
+		"blog/2023/_ssg.json":   `{"markdown": {"flags": ["common", "href-target-blank"]}}`,
+		"blog/2023/_nav.md":     "[2023](https://example.com/2023)",
+		"blog/2023/index.md":    "# 2023",
+		"blogs/index.md":        "# Blogs",
+		"blogs/_sidebar.html":   "<b>Blogs</b>",
+		"blogs/_sidebar.md.txt": "not a slot",
+	})
+
+	s := New(src, dst, "TestSlots", "https://example.com")
+	s.With(Caching(true))
+	_, outputs, err := s.Build(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expecteds := map[string]string{
+		"index.html":            "<nav><p><a href=\"/\">Home</a></p>\n</nav>\n<h1 id=\"home\">Home</h1>\n<aside></aside>\n",
+		"blog/index.html":       "<nav><a href=\"/blog\">Blog</a></nav>\n<h1 id=\"blog\">Blog</h1>\n<aside><p><em>Archive</em></p>\n</aside>\n",
+		"blog/2023/index.html":  "<nav><p><a href=\"https://example.com/2023\" target=\"_blank\">2023</a></p>\n</nav>\n<h1 id=\"2023\">2023</h1>\n<aside><p><em>Archive</em></p>\n</aside>\n",
+		"blogs/index.html":      "<nav><p><a href=\"/\">Home</a></p>\n</nav>\n<h1 id=\"blogs\">Blogs</h1>\n<aside><b>Blogs</b></aside>\n",
+		"blogs/_sidebar.md.txt": "not a slot",
+	}
+
+	if len(outputs) != len(expecteds) {
+		for i := range outputs {
+			t.Logf("output: %s", outputs[i].target)
+		}
+		t.Fatalf("unexpected number of outputs: expected=%d, actual=%d", len(expecteds), len(outputs))
+	}
+	for i := range outputs {
+		o := &outputs[i]
+		rel, err := filepath.Rel(dst, o.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, ok := expecteds[rel]
+		if !ok {
+			t.Fatalf("unexpected output '%s'", rel)
+		}
+		if string(o.data) != expected {
+			t.Logf("expected:\n%s", expected)
+			t.Logf("actual:\n%s", o.data)
+			t.Fatalf("unexpected output data for '%s'", rel)
+		}
+	}
+
+	resolution := s.Resolve(filepath.Join(src, "blog/2023/index.md"))
+	if resolution.Nav != filepath.Join(src, "blog/2023/_nav.md") {
+		t.Fatalf("unexpected nav resolution '%s'", resolution.Nav)
+	}
+	if resolution.Sidebar != filepath.Join(src, "blog/_sidebar.md") {
+		t.Fatalf("unexpected sidebar resolution '%s'", resolution.Sidebar)
+	}
+
+	writeFiles(t, src, map[string]string{"blog/_nav.md": "[Blog](/blog)"})
+	_, _, err = Build(src, dst, "TestSlots", "https://example.com", nil)
+	if err == nil {
+		t.Fatalf("unexpected nil error for conflicting _nav.md and _nav.html")
+	}
+}
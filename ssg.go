@@ -2,6 +2,7 @@ package ssg
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
@@ -11,6 +12,13 @@ import (
 	"github.com/sabhiram/go-gitignore"
 )
 
+var (
+	ErrEmptySrc     = errors.New("empty src")
+	ErrEmptyDst     = errors.New("empty dst")
+	ErrSrcIsDst     = errors.New("src is identical to dst")
+	ErrBadSsgIgnore = errors.New("bad ssgignore")
+)
+
 type Ssg struct {
 	Src   string
 	Dst   string
@@ -23,30 +31,77 @@ type Ssg struct {
 	headers    headers
 	footers    footers
 	preferred  Set // Used to prefer html and ignore md files with identical names, as with the original ssg
+	included   Set // Files included by pages and templates, which are not output on their own
+
+	pageHeaders map[string]header // Per-page header overrides, keyed by filename
+	pageFooters map[string]footer // Per-page footer overrides, keyed by filename
+	layouts     map[string]layout // Named layouts from _layouts
+
+	headersGemini perDir[*bytes.Buffer]
+	footersGemini perDir[*bytes.Buffer]
+	configs       perDir[DirConfig]
+	navs          perDir[slot]
+	sidebars      perDir[slot]
+
+	tags      map[string]*tag // Tags collected during build, keyed by slug
+	redirects []redirect      // Redirects from _redirects and page aliases
+	errs      BuildErrors     // Errors collected with ContinueOnError
+	errInit   error           // Errors from New and options, returned by Build and Generate
+	skipDsts  Set             // dst and gemini dst inside src, skipped with AllowDstInSrc
+	following []string        // Resolved directories of symlinks being followed
+	indexDirs []string        // Directories walked, in walk order, for generated indexes
+	indexed   Set             // Output directories with index.html
 
 	result buildOutput
 }
 
+// Resolution reports which cascading templates and configuration
+// are chosen for a path. Each field is the path of the marker file
+// or per-page override chosen, or empty if the default is used.
+type Resolution struct {
+	Header       string
+	Footer       string
+	HeaderGemini string
+	FooterGemini string
+	Nav          string
+	Sidebar      string
+
+	// Config is the nearest _ssg.json,
+	// whose values are merged with its ancestors' values.
+	Config string
+}
+
 func (s *Ssg) Options() Options { return s.options }
 func (s *Ssg) Outputs() Outputs { return &s.result }
 
 // New returns a default [Ssg] with options.
+// If src or dst is invalid, or if .ssgignore cannot be parsed,
+// the error is returned by Build and Generate.
 func New(src, dst, title, url string) Ssg {
+	ignores, err := prepare(src, dst)
 	src = filepath.Clean(src)
 	dst = filepath.Clean(dst)
-	ignores, err := prepare(src, dst)
-	if err != nil {
-		panic(err)
-	}
 	s := Ssg{
 		Src:        src,
 		Dst:        dst,
 		Title:      title,
 		URL:        url,
+		options:    options{markdown: DefaultMarkdown()},
 		ssgignores: ignores.Ignore,
 		preferred:  make(Set),
 		headers:    newHeaders(HeaderDefault),
 		footers:    newFooters(FooterDefault),
+
+		pageHeaders: make(map[string]header),
+		pageFooters: make(map[string]footer),
+
+		headersGemini: newPerDir(bytes.NewBuffer(nil)),
+		footersGemini: newPerDir(bytes.NewBuffer(nil)),
+		configs:       newPerDir(DirConfig{}),
+		navs:          newPerDir(slot{}),
+		sidebars:      newPerDir(slot{}),
+
+		errInit: err,
 	}
 	return s
 }
@@ -57,6 +112,22 @@ func NewWithOptions(src, dst, title, url string, opts ...Option) *Ssg {
 	return &s
 }
 
+// NewChecked is like [NewWithOptions], but returns errors from src, dst,
+// .ssgignore and opts right away instead of from Build and Generate.
+// It does not check dst for writes, see [Ssg.Generate].
+func NewChecked(src, dst, title, url string, opts ...Option) (*Ssg, error) {
+	s := NewWithOptions(src, dst, title, url, opts...)
+	err := s.Err()
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Err returns errors from New and options applied so far,
+// which are otherwise returned by Build and Generate
+func (s *Ssg) Err() error { return s.errInit }
+
 // Build builds static site from src.
 // If outputs is nil, the result will only be cached.
 // If outputs is non-nil, then the builder's outputs
@@ -97,7 +168,8 @@ func (s *Ssg) Generate() error {
 	return generate(s)
 }
 
-// With applies opts to s sequentially
+// With applies opts to s sequentially.
+// Errors from invalid options are returned by Build and Generate.
 func (s *Ssg) With(opts ...Option) *Ssg {
 	for i := range opts {
 		opts[i](s)
@@ -111,21 +183,29 @@ func (s *Ssg) collect(path string) error {
 		return err
 	}
 
+	names := make(Set)
+	for i := range children {
+		names.Insert(children[i].Name())
+	}
+
+	var slots []string
 	for i := range children {
 		child := children[i]
 		base := child.Name()
 		pathChild := filepath.Join(path, base)
 
+		if isSlot(base) {
+			slots = append(slots, pathChild)
+			continue
+		}
+
 		switch base {
 		case MarkerHeader:
-			data, err := ReadFile(pathChild)
+			h, err := s.readHeader(pathChild)
 			if err != nil {
 				return err
 			}
-			err = s.headers.add(path, header{
-				Buffer:    bytes.NewBuffer(data),
-				titleFrom: GetTitleFrom(data),
-			})
+			err = s.headers.add(path, h)
 			if err != nil {
 				return err
 			}
@@ -133,20 +213,107 @@ func (s *Ssg) collect(path string) error {
 			continue
 
 		case MarkerFooter:
+			f, err := s.readFooter(pathChild)
+			if err != nil {
+				return err
+			}
+			err = s.footers.add(path, f)
+			if err != nil {
+				return err
+			}
+
+			continue
+
+		case MarkerHeaderNone, MarkerHeaderDefault:
+			h := header{
+				Buffer:    bytes.NewBuffer(nil),
+				titleFrom: TitleFromNone,
+				source:    pathChild,
+			}
+			if base == MarkerHeaderDefault {
+				h = s.headers.defaultValue
+				h.source = pathChild
+			}
+			err := s.headers.add(path, h)
+			if err != nil {
+				return fmt.Errorf("%s: %w", pathChild, err)
+			}
+
+			continue
+
+		case MarkerFooterNone, MarkerFooterDefault:
+			f := footer{
+				Buffer: bytes.NewBuffer(nil),
+				source: pathChild,
+			}
+			if base == MarkerFooterDefault {
+				f = s.footers.defaultValue
+				f.source = pathChild
+			}
+			err := s.footers.add(path, f)
+			if err != nil {
+				return fmt.Errorf("%s: %w", pathChild, err)
+			}
+
+			continue
+
+		case MarkerConfig:
 			data, err := ReadFile(pathChild)
 			if err != nil {
 				return err
 			}
-			err = s.footers.add(path, bytes.NewBuffer(data))
+			err = s.collectConfig(path, data)
 			if err != nil {
 				return err
 			}
 
 			continue
+
+		case MarkerHeaderGemini, MarkerFooterGemini:
+			if s.options.gemini == "" {
+				continue
+			}
+			data, err := ReadFile(pathChild)
+			if err != nil {
+				return err
+			}
+			templates := &s.headersGemini
+			if base == MarkerFooterGemini {
+				templates = &s.footersGemini
+			}
+			err = templates.add(path, bytes.NewBuffer(data))
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		// Per-page templates without a page are output as usual
+		switch {
+		case strings.HasSuffix(base, ExtPageHeader) && s.hasPage(names, strings.TrimSuffix(base, ExtPageHeader)):
+			h, err := s.readHeader(pathChild)
+			if err != nil {
+				return err
+			}
+			s.pageHeaders[pathChild] = h
+			continue
+
+		case strings.HasSuffix(base, ExtPageFooter) && s.hasPage(names, strings.TrimSuffix(base, ExtPageFooter)):
+			f, err := s.readFooter(pathChild)
+			if err != nil {
+				return err
+			}
+			s.pageFooters[pathChild] = f
+			continue
 		}
 
 		ext := filepath.Ext(base)
-		if ext != ".html" {
+		if ext != ".html" || IsFragment(base) || s.included.Contains(pathChild) {
+			continue
+		}
+		// Symlinks are only preferred if they are output
+		if isSymlink(child) && !s.outputsSymlink(pathChild) {
 			continue
 		}
 		if s.preferred.Insert(pathChild) {
@@ -154,69 +321,187 @@ func (s *Ssg) collect(path string) error {
 		}
 	}
 
+	// Slots are rendered after this directory's _ssg.json is collected
+	for i := range slots {
+		err := s.collectSlot(path, slots[i])
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// hasPage reports whether names has a page with stem,
+// to which per-page templates stem.header.html and stem.footer.html apply
+func (s *Ssg) hasPage(names Set, stem string) bool {
+	exts := []string{".html", ".md", ExtFragment}
+	for ext := range s.options.converters {
+		exts = append(exts, ext)
+	}
+	for _, ext := range exts {
+		if names.Contains(stem + ext) {
+			return true
+		}
+	}
+	return false
+}
+
 // core does 2 things:
-// - If path extension is not .md, then the current file will
+// - If path has no registered [Converter], then the current file will
 // simply be copied to outputs.
-// - If path has .md extension, it converts Markdown to HTML
+// - If path has a converter (e.g. .md), it converts the file to HTML
 // and adds a new output with .html extension
-func (s *Ssg) core(path string, data []byte, d fs.DirEntry) (OutputFile, error) {
+//
+// If gemtext output is enabled, core also adds the gemtext output.
+func (s *Ssg) core(path string, data []byte, d fs.DirEntry) ([]OutputFile, error) {
 	info, err := d.Info()
 	if err != nil {
-		return OutputFile{}, err
+		return nil, err
+	}
+	ext, converter := s.options.converter(path, s.markdown(path))
+
+	// Includes are spliced before hooks, so that hooks also see included data
+	if converter != nil {
+		data, err = Include(s.Src, path, data)
+		if err != nil {
+			return nil, err
+		}
 	}
 	for i, hook := range s.options.hooks {
 		data, err = hook(path, data)
 		if err != nil {
-			return OutputFile{}, fmt.Errorf("hooks[%d]: error when building %s: %w", i, path, err)
+			return nil, stageError(path, StageHook, i, err)
 		}
 	}
-	target, err := mirrorPath(s.Src, s.Dst, path)
-	if err != nil {
-		return OutputFile{}, err
+
+	perm := info.Mode().Perm()
+	raw := data
+
+	var page directives
+	if converter != nil {
+		page, data = parseDirectives(data)
+
+		published, err := s.published(path, page)
+		if err != nil {
+			return nil, err
+		}
+		if !published {
+			return nil, nil
+		}
+	}
+
+	var output OutputFile
+	switch {
+	// Copy unconvertible files and files with preferred HTML
+	case converter == nil, s.preferred.Contains(ChangeExt(path, ext, ".html")):
+		target, err := mirrorPath(s.Src, s.Dst, path)
+		if err != nil {
+			return nil, err
+		}
+		output = Output(target, path, raw, perm)
+
+	default:
+		output, err = s.coreHTML(path, ext, converter, data, page, perm)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// Copy non-Markdown and HTML files
-	if ext := filepath.Ext(path); ext != ".md" || s.preferred.Contains(
-		ChangeExt(path, ".md", ".html"),
-	) {
-		// Just copy the file to the destination
-		return Output(
-			target,
-			path,
-			data,
-			info.Mode().Perm(),
-		), nil
+	output.noSitemap = !s.sitemap(path)
+	if s.options.gemini == "" {
+		return []OutputFile{output}, nil
 	}
 
-	// foo.md -> foo.html
-	target = ChangeExt(target, ".md", ".html")
-	header := s.headers.choose(path)
-	footer := s.footers.choose(path)
+	if filepath.Ext(path) != ".md" {
+		data = raw
+	}
+	gemini, err := s.coreGemini(path, data, perm)
+	if err != nil {
+		return nil, err
+	}
+	return []OutputFile{output, gemini}, nil
+}
+
+// coreHTML converts data with converter and assembles the HTML output
+func (s *Ssg) coreHTML(
+	path string,
+	ext string,
+	converter Converter,
+	data []byte,
+	page directives,
+	perm fs.FileMode,
+) (
+	OutputFile,
+	error,
+) {
+	templates := path
+	if page.templates != "" {
+		templates = page.templates
+	}
+	header, footer, err := s.templates(templates, ext, page.layout)
+	if err != nil {
+		return OutputFile{}, err
+	}
 
 	// Copy data from header and leave the header data unchanged
 	headerText := make([]byte, header.Len())
 	_ = copy(headerText, header.Bytes())
 
-	switch header.titleFrom {
-	case TitleFromH1:
-		headerText = AddTitleFromH1([]byte(s.Title), headerText, data)
+	title := s.title(path)
+	titleFrom := header.titleFrom
+	if c := s.configs.choose(path); c.TitleFrom != nil {
+		headerText, titleFrom = retargetTitle(title, headerText, titleFrom, *c.TitleFrom)
+	}
+
+	pageTitle := GetTitleFromTag(data)
+	if titleFrom == TitleFromTag {
+		headerText, data = AddTitleFromTag(title, headerText, data)
+	}
+
+	body, meta, err := converter.Convert(path, data)
+	if err != nil {
+		return OutputFile{}, stageError(path, StageConvert, 0, err)
+	}
 
-	case TitleFromTag:
-		headerText, data = AddTitleFromTag([]byte(s.Title), headerText, data)
+	if titleFrom == TitleFromH1 {
+		headerText = replaceTitle(title, headerText, []byte(TargetFromH1), meta.Title)
+	}
+
+	if len(pageTitle) == 0 {
+		pageTitle = meta.Title
+	}
+	target, rebase, err := s.pageTarget(path, ext, pageTitle, page)
+	if err != nil {
+		return OutputFile{}, err
+	}
+	err = s.addTagged(target, pageTitle, page)
+	if err != nil {
+		return OutputFile{}, fmt.Errorf("tags error: %w", err)
+	}
+	err = s.addAliases(target, path, page)
+	if err != nil {
+		return OutputFile{}, fmt.Errorf("aliases error: %w", err)
 	}
 
 	// HTML output buffer
-	buf := bytes.NewBuffer(headerText)
-	buf.Write(ToHTML(data))
-	buf.Write(footer.Bytes())
+	buf := bytes.NewBuffer(s.fillSlots(templates, headerText))
+	buf.Write(body)
+	buf.Write(s.fillSlots(templates, footer.Bytes()))
+
+	// Relative URLs are relative to the source,
+	// so they are rebased if the output is moved, e.g. with pretty URLs
+	if s.options.prettyURLs {
+		buf = bytes.NewBuffer(s.prettyLinks(path, buf.Bytes()))
+	}
+	if rebase != "" {
+		buf = bytes.NewBuffer(RebaseURLs(buf.Bytes(), rebase))
+	}
 
 	for i, h := range s.options.hookGenerate {
 		b, err := h(buf.Bytes())
 		if err != nil {
-			return OutputFile{}, fmt.Errorf("hooksGenerate[%d] error when building %s: %w", i, path, err)
+			return OutputFile{}, stageError(path, StageHookGenerate, i, err)
 		}
 		buf = bytes.NewBuffer(b)
 	}
@@ -225,10 +510,114 @@ func (s *Ssg) core(path string, data []byte, d fs.DirEntry) (OutputFile, error)
 		target,
 		path,
 		buf.Bytes(),
-		info.Mode().Perm(),
+		perm,
 	), nil
 }
 
+// readHeader reads header template at path, with includes expanded
+func (s *Ssg) readHeader(path string) (header, error) {
+	data, err := ReadFile(path)
+	if err != nil {
+		return header{}, err
+	}
+	data, err = Include(s.Src, path, data)
+	if err != nil {
+		return header{}, err
+	}
+	return header{
+		Buffer:    bytes.NewBuffer(data),
+		titleFrom: GetTitleFrom(data),
+		source:    path,
+	}, nil
+}
+
+// readFooter reads footer template at path, with includes expanded
+func (s *Ssg) readFooter(path string) (footer, error) {
+	data, err := ReadFile(path)
+	if err != nil {
+		return footer{}, err
+	}
+	data, err = Include(s.Src, path, data)
+	if err != nil {
+		return footer{}, err
+	}
+	return footer{
+		Buffer: bytes.NewBuffer(data),
+		source: path,
+	}, nil
+}
+
+// coreGemini renders Markdown files at path to gemtext,
+// and simply copies other files to the gemtext destination.
+func (s *Ssg) coreGemini(path string, data []byte, perm fs.FileMode) (OutputFile, error) {
+	target, err := mirrorPath(s.Src, s.options.gemini, path)
+	if err != nil {
+		return OutputFile{}, err
+	}
+	if filepath.Ext(path) != ".md" {
+		return Output(target, path, data, perm), nil
+	}
+
+	header := s.headersGemini.choose(path)
+	footer := s.footersGemini.choose(path)
+
+	headerText := make([]byte, header.Len())
+	_ = copy(headerText, header.Bytes())
+	title := s.title(path)
+	headerText, data = AddTitleFromTag(title, headerText, data)
+	headerText = AddTitleFromH1(title, headerText, data)
+
+	buf := bytes.NewBuffer(headerText)
+	buf.Write(ToGemtext(data, s.markdown(path).Extensions))
+	buf.Write(footer.Bytes())
+
+	return Output(
+		ChangeExt(target, ".md", ".gmi"),
+		path,
+		buf.Bytes(),
+		perm,
+	), nil
+}
+
+// Resolve reports which cascading templates and configuration are chosen
+// for path, which can be a file or a directory under s.Src.
+//
+// The templates are collected during builds, so Resolve
+// reports the templates collected by the last build.
+func (s *Ssg) Resolve(path string) Resolution {
+	marker := func(dir string, name string) string {
+		if dir == "" {
+			return ""
+		}
+		return filepath.Join(dir, name)
+	}
+
+	ext, converter := s.options.converter(path, s.markdown(path))
+	var page directives
+	if converter != nil {
+		data, err := ReadFile(path)
+		if err == nil {
+			page, _ = parseDirectives(data)
+		}
+	}
+	header, footer, _ := s.templates(path, ext, page.layout)
+	_, headerGemini := s.headersGemini.resolve(path)
+	_, footerGemini := s.footersGemini.resolve(path)
+	_, config := s.configs.resolve(path)
+	nav, _ := s.navs.resolve(path)
+	sidebar, _ := s.sidebars.resolve(path)
+
+	return Resolution{
+		Header:       header.source,
+		Footer:       footer.source,
+		HeaderGemini: marker(headerGemini, MarkerHeaderGemini),
+		FooterGemini: marker(footerGemini, MarkerFooterGemini),
+		Config:       marker(config, MarkerConfig),
+		Nav:          nav.source,
+		Sidebar:      sidebar.source,
+	}
+}
+
 func (s *Ssg) Ignore(path string) bool {
 	return s.ssgignores(path)
 }
@@ -239,13 +628,17 @@ func (s *Ssg) pront(l int) {
 
 func prepare(src, dst string) (*SsgIgnore, error) {
 	if src == "" {
-		return nil, fmt.Errorf("empty src")
+		return nil, ErrEmptySrc
 	}
 	if dst == "" {
-		return nil, fmt.Errorf("empty dst")
+		return nil, ErrEmptyDst
 	}
+	src, dst = filepath.Clean(src), filepath.Clean(dst)
 	if src == dst {
-		return nil, fmt.Errorf("src is identical to dst: '%s'", src)
+		return nil, fmt.Errorf("%w: '%s'", ErrSrcIsDst, src)
+	}
+	if stat, err := os.Stat(src); err == nil && !stat.IsDir() {
+		return nil, fmt.Errorf("%w: '%s'", ErrSrcNotDir, src)
 	}
 	ssgignore := filepath.Join(src, MarkerSsgIgnore)
 	return ParseSsgIgnore(ssgignore)
@@ -257,7 +650,7 @@ func ParseSsgIgnore(path string) (*SsgIgnore, error) {
 		if os.IsNotExist(err) {
 			return nil, nil
 		}
-		return nil, fmt.Errorf("failed to parse ssgignore at %s: %w", path, err)
+		return nil, fmt.Errorf("%w at %s: %w", ErrBadSsgIgnore, path, err)
 	}
 	return &SsgIgnore{GitIgnore: ignores}, nil
 }
@@ -292,19 +685,6 @@ func shouldIgnore(ignoreFn func(path string) (ignored bool), path, base string,
 	case ignoreFn(path):
 		return true, nil
 	}
-
-	// Ignore symlink
-	stat, err := os.Stat(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return true, nil
-		}
-		return false, err
-	}
-
-	if FileIs(stat, os.ModeSymlink) {
-		return true, nil
-	}
 	return false, nil
 }
 
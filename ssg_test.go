@@ -186,7 +186,7 @@ func testGenerate(t *testing.T, buildFn func(s *Ssg) ([]string, []OutputFile, er
 	for h, from := range titleFroms {
 		filename := filepath.Join(src, h)
 		dirname := filepath.Dir(filename)
-		header, ok := s.headers.values[dirname]
+		header, ok := s.headers.get(dirname)
 		if !ok {
 			t.Fatalf("missing header '%s' for dir '%s'", filename, dirname)
 		}
@@ -242,6 +242,36 @@ func testGenerate(t *testing.T, buildFn func(s *Ssg) ([]string, []OutputFile, er
 		}
 	}
 
+	resolutions := map[string]Resolution{
+		"/index.md": {
+			Header: "/_header.html",
+			Footer: "/_footer.html",
+		},
+		"/blog/2023/bar.md": {
+			Header: "/blog/2023/_header.html",
+			Footer: "/blog/2023/_footer.html",
+		},
+		"/blog/2022/3/article_1.md": {
+			Header: "/blog/_header.html",
+			Footer: "/blog/_footer.html",
+		},
+		"/notes": {
+			Header: "/notes/_header.html",
+			Footer: "/_footer.html",
+		},
+	}
+
+	for path, e := range resolutions {
+		actual := s.Resolve(filepath.Join(src, path))
+		expected := Resolution{
+			Header: filepath.Join(src, e.Header),
+			Footer: filepath.Join(src, e.Footer),
+		}
+		if actual != expected {
+			t.Fatalf("unexpected resolution for '%s': actual=%+v, expecting=%+v", path, actual, expected)
+		}
+	}
+
 	expectedOutputs := map[string][]string{
 		"/index.html": {
 			"<title>Welcome to JohnDoe.com!</title>",
@@ -254,6 +284,12 @@ func testGenerate(t *testing.T, buildFn func(s *Ssg) ([]string, []OutputFile, er
 			"<!-- Header for testconvert -->",
 			"<title>Embedded-HTML should be correctly preserved</title>",
 		},
+		"/testfragment/table.html": {
+			"<!-- ROOT HEADER -->",
+			"<title>Fragment table</title>",
+			"<body><h1 id=\"fragment-table\">Fragment <em>table</em></h1>",
+			"<p><a href=\"#top\">Back to top</a></p>",
+		},
 	}
 
 	for path, e := range expectedOutputs {
@@ -456,3 +492,251 @@ func TestErrors(t *testing.T) {
 		}
 	}
 }
+
+func TestToHTMLHeadings(t *testing.T) {
+	type testCase struct {
+		markdown Markdown
+		md       string
+		html     string
+	}
+
+	md := `# Top
+
+{{toc}}
+
+## A
+
+### A1
+
+#### A1a
+
+## B ` + "`code`" + `
+
+# Second
+
+Not a {{toc}}`
+
+	tests := []testCase{
+		{
+			markdown: DefaultMarkdown(),
+			md:       md,
+			html: `<h1 id="top">Top</h1>
+
+<nav class="ssg-toc">
+<ul>
+<li><a href="#top">Top</a>
+<ul>
+<li><a href="#a">A</a>
+<ul>
+<li><a href="#a1">A1</a>
+<ul>
+<li><a href="#a1a">A1a</a></li>
+</ul>
+</li>
+</ul>
+</li>
+<li><a href="#b-code">B code</a></li>
+</ul>
+</li>
+<li><a href="#second">Second</a></li>
+</ul>
+</nav>
+
+<h2 id="a">A</h2>
+
+<h3 id="a1">A1</h3>
+
+<h4 id="a1a">A1a</h4>
+
+<h2 id="b-code">B <code>code</code></h2>
+
+<h1 id="second">Second</h1>
+
+<p>Not a {{toc}}</p>
+`,
+		},
+		{
+			markdown: Markdown{
+				Extensions:    SsgExtensions,
+				Flags:         HTMLFlags,
+				HeadingAnchor: "#",
+				TOCDepth:      2,
+			},
+			md: md,
+			html: `<h1 id="top">Top <a class="ssg-anchor" href="#top">#</a></h1>
+
+<nav class="ssg-toc">
+<ul>
+<li><a href="#top">Top</a>
+<ul>
+<li><a href="#a">A</a></li>
+<li><a href="#b-code">B code</a></li>
+</ul>
+</li>
+<li><a href="#second">Second</a></li>
+</ul>
+</nav>
+
+<h2 id="a">A <a class="ssg-anchor" href="#a">#</a></h2>
+
+<h3 id="a1">A1 <a class="ssg-anchor" href="#a1">#</a></h3>
+
+<h4 id="a1a">A1a <a class="ssg-anchor" href="#a1a">#</a></h4>
+
+<h2 id="b-code">B <code>code</code> <a class="ssg-anchor" href="#b-code">#</a></h2>
+
+<h1 id="second">Second <a class="ssg-anchor" href="#second">#</a></h1>
+
+<p>Not a {{toc}}</p>
+`,
+		},
+		{
+			markdown: DefaultMarkdown(),
+			md:       "{{toc}}\n\n# A\n\n### C\n\n## B\n",
+			html: `<nav class="ssg-toc">
+<ul>
+<li><a href="#a">A</a>
+<ul>
+<li><a href="#c">C</a></li>
+<li><a href="#b">B</a></li>
+</ul>
+</li>
+</ul>
+</nav>
+
+<h1 id="a">A</h1>
+
+<h3 id="c">C</h3>
+
+<h2 id="b">B</h2>
+`,
+		},
+		{
+			markdown: DefaultMarkdown(),
+			md:       "{{toc}}\n\n## Quote {#a\"b<c}\n",
+			html: `<nav class="ssg-toc">
+<ul>
+<li><a href="#a&#34;b&lt;c">Quote</a></li>
+</ul>
+</nav>
+
+<h2 id="a"b<c">Quote</h2>
+`,
+		},
+		{
+			markdown: DefaultMarkdown(),
+			md:       "{{toc}}\n\nNo headings",
+			html: `<nav class="ssg-toc">
+</nav>
+
+<p>No headings</p>
+`,
+		},
+	}
+
+	for i := range tests {
+		tc := &tests[i]
+		html := tc.markdown.ToHTML([]byte(tc.md))
+		if actual := string(html); actual != tc.html {
+			t.Logf("expected:\n%s", tc.html)
+			t.Logf("actual:\n%s", actual)
+			t.Fatalf("unexpected HTML output from case %d", i+1)
+		}
+	}
+}
+
+func TestTemplateOverrides(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	files := map[string]string{
+		"_header.html":                 "<!-- ROOT HEADER -->\n<title>{{from-h1}}</title>\n",
+		"_footer.html":                 "<!-- ROOT FOOTER -->\n",
+		"index.md":                     "# Home",
+		"landing/_header.none":         "",
+		"landing/index.md":             "# Landing",
+		"landing/widget/_footer.none":  "",
+		"landing/widget/_header.html":  "<!-- WIDGET HEADER -->\n",
+		"landing/widget/index.md":      "# Widget",
+		"reset/_header.default":        "",
+		"reset/_footer.default":        "",
+		"reset/index.md":               "# Reset",
+		"reset/special.md":             "# Special",
+		"reset/special.header.html":    "<!-- SPECIAL HEADER {{from-h1}} -->\n",
+		"reset/special.footer.html":    "<!-- SPECIAL FOOTER -->\n",
+		"reset/table.frag.html":        "<h1>Table</h1>",
+		"reset/table.footer.html":      "<!-- TABLE FOOTER -->\n",
+		"reset/not-a-page.header.html": "<!-- NOT A TEMPLATE WITHOUT not-a-page.md -->\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(src, name)
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := New(src, dst, "TestTemplateOverrides", "https://example.com")
+	s.With(Caching(true))
+	_, outputs, err := s.Build(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expecteds := map[string]string{
+		"index.html":                "<!-- ROOT HEADER -->\n<title>Home</title>\n<h1 id=\"home\">Home</h1>\n<!-- ROOT FOOTER -->\n",
+		"landing/index.html":        "<h1 id=\"landing\">Landing</h1>\n<!-- ROOT FOOTER -->\n",
+		"landing/widget/index.html": "<!-- WIDGET HEADER -->\n<h1 id=\"widget\">Widget</h1>\n",
+		"reset/index.html":          strings.Replace(HeaderDefault, TargetFromH1, "Reset", 1) + "<h1 id=\"reset\">Reset</h1>\n" + FooterDefault,
+		"reset/special.html":        "<!-- SPECIAL HEADER Special -->\n<h1 id=\"special\">Special</h1>\n<!-- SPECIAL FOOTER -->\n",
+		"reset/table.html":          strings.Replace(HeaderDefault, TargetFromH1, "Table", 1) + "<h1>Table</h1><!-- TABLE FOOTER -->\n",
+
+		// Output as before per-page templates, since there is no page not-a-page
+		"reset/not-a-page.header.html": "<!-- NOT A TEMPLATE WITHOUT not-a-page.md -->\n",
+	}
+
+	if len(outputs) != len(expecteds) {
+		for i := range outputs {
+			t.Logf("output: %s", outputs[i].target)
+		}
+		t.Fatalf("unexpected number of outputs: expected=%d, actual=%d", len(expecteds), len(outputs))
+	}
+	for i := range outputs {
+		o := &outputs[i]
+		rel, err := filepath.Rel(dst, o.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, ok := expecteds[rel]
+		if !ok {
+			t.Fatalf("unexpected output '%s'", rel)
+		}
+		if string(o.data) != expected {
+			t.Logf("expected:\n%s", expected)
+			t.Logf("actual:\n%s", o.data)
+			t.Fatalf("unexpected output data for '%s'", rel)
+		}
+	}
+
+	resolution := s.Resolve(filepath.Join(src, "reset/special.md"))
+	if resolution.Header != filepath.Join(src, "reset/special.header.html") {
+		t.Fatalf("unexpected header resolution '%s'", resolution.Header)
+	}
+	resolution = s.Resolve(filepath.Join(src, "landing/widget/index.md"))
+	if resolution.Footer != filepath.Join(src, "landing/widget/_footer.none") {
+		t.Fatalf("unexpected footer resolution '%s'", resolution.Footer)
+	}
+
+	err = os.WriteFile(filepath.Join(src, "landing/_header.html"), nil, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = Build(src, dst, "TestTemplateOverrides", "https://example.com", nil)
+	if err == nil {
+		t.Fatalf("unexpected nil error for conflicting _header.html and _header.none")
+	}
+}
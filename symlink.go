@@ -0,0 +1,137 @@
+package ssg
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// SymlinkPolicy is how symlinks in src are handled
+type SymlinkPolicy uint8
+
+const (
+	SymlinkIgnore SymlinkPolicy = iota // Symlinks are skipped
+	SymlinkFollow                      // Symlinks are built as their targets, including directories
+	SymlinkCopy                        // Symlinks are recreated in dst with the same link targets
+)
+
+var ErrSymlinkCycle = errors.New("symlink cycle")
+
+func (p *SymlinkPolicy) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "ignore":
+		*p = SymlinkIgnore
+	case "follow":
+		*p = SymlinkFollow
+	case "copy":
+		*p = SymlinkCopy
+	default:
+		return fmt.Errorf("unknown symlink policy '%s', expecting ignore, follow or copy", text)
+	}
+	return nil
+}
+
+func (p SymlinkPolicy) String() string {
+	switch p {
+	case SymlinkIgnore:
+		return "ignore"
+	case SymlinkFollow:
+		return "follow"
+	case SymlinkCopy:
+		return "copy"
+	}
+	return fmt.Sprintf("SymlinkPolicy(%d)", uint8(p))
+}
+
+func (p SymlinkPolicy) MarshalText() ([]byte, error) {
+	switch p {
+	case SymlinkIgnore, SymlinkFollow, SymlinkCopy:
+		return []byte(p.String()), nil
+	}
+	return nil, fmt.Errorf("unknown symlink policy %d", uint8(p))
+}
+
+// walkSymlink handles symlink at path according to the symlink policy.
+//
+// With SymlinkFollow, linked directories are walked as if they were
+// directories in src at path. Links to a directory that is already
+// being walked are reported as ErrSymlinkCycle. Broken links are skipped.
+func (s *Ssg) walkSymlink(path string) error {
+	switch s.options.symlinks {
+	case SymlinkCopy:
+		link, err := os.Readlink(path)
+		if err != nil {
+			return s.fail(stageError(path, StageRead, 0, err))
+		}
+		target, err := mirrorPath(s.Src, s.Dst, path)
+		if err != nil {
+			return s.fail(stageError(path, StageCore, 0, err))
+		}
+		s.result.files = append(s.result.files, path)
+		return s.fail(s.addOutputs(path, OutputFile{
+			target:     target,
+			originator: path,
+			symlink:    link,
+			noSitemap:  true,
+		}))
+
+	case SymlinkFollow:
+		stat, err := os.Stat(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return s.fail(stageError(path, StageRead, 0, err))
+		}
+		if !stat.IsDir() {
+			return s.walk(path, fs.FileInfoToDirEntry(stat), nil)
+		}
+
+		resolved, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return s.fail(stageError(path, StageRead, 0, err))
+		}
+		parent, err := filepath.EvalSymlinks(filepath.Dir(path))
+		if err != nil {
+			return s.fail(stageError(path, StageRead, 0, err))
+		}
+		for _, walking := range append(s.following, parent) {
+			if resolved == walking || isWithin(resolved, walking) {
+				return s.fail(stageError(path, StageRead, 0, fmt.Errorf("%w: '%s' links to '%s'", ErrSymlinkCycle, path, resolved)))
+			}
+		}
+
+		s.following = append(s.following, resolved)
+		defer func() { s.following = s.following[:len(s.following)-1] }()
+
+		return filepath.WalkDir(resolved, func(p string, d fs.DirEntry, err error) error {
+			rel, errRel := filepath.Rel(resolved, p)
+			if errRel != nil {
+				return errRel
+			}
+			return s.walk(filepath.Join(path, rel), d, err)
+		})
+	}
+
+	return nil
+}
+
+// outputsSymlink reports whether symlink at path to a file is output
+// according to the symlink policy
+func (s *Ssg) outputsSymlink(path string) bool {
+	switch s.options.symlinks {
+	case SymlinkCopy:
+		return true
+	case SymlinkFollow:
+		stat, err := os.Stat(path)
+		return err == nil && !stat.IsDir()
+	}
+	return false
+}
+
+// isSymlink returns whether d is a symlink
+func isSymlink(d fs.DirEntry) bool {
+	return d.Type()&fs.ModeSymlink != 0
+}
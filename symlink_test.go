@@ -0,0 +1,241 @@
+package ssg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSymlinks(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"shared/style.css":       "body {}",
+		"shared/page.md":         "# Shared",
+		"shared/other.md":        "# Other",
+		"templates/_header.html": "<header>\n",
+		"templates/_signup.md":   "Sign up",
+		"src/_footer.html":       "",
+		"src/index.md":           "# Index\n\n:ssg-include _signup.md",
+	})
+	src := filepath.Join(root, "src")
+	links := map[string]string{
+		"assets":    filepath.Join(root, "shared"),
+		"linked.md": filepath.Join("..", "shared", "other.md"),
+		"broken.md": filepath.Join(root, "missing.md"),
+
+		// Shared templates and partials are never output
+		"_header.html": filepath.Join("..", "templates", "_header.html"),
+		"_signup.md":   filepath.Join("..", "templates", "_signup.md"),
+	}
+	for name, link := range links {
+		err := os.Symlink(link, filepath.Join(src, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := map[SymlinkPolicy][]string{
+		SymlinkIgnore: {"index.html"},
+		SymlinkFollow: {"index.html", "assets/style.css", "assets/page.html", "assets/other.html", "linked.html"},
+		SymlinkCopy:   {"index.html", "assets", "linked.md", "broken.md"},
+	}
+	for policy, expecteds := range tests {
+		dst := filepath.Join(t.TempDir(), "dst")
+		_, outputs, err := Build(src, dst, "TestSymlinks", "https://example.com", nil, Symlinks(policy))
+		if err != nil {
+			t.Fatalf("[policy %s] unexpected error: %v", policy, err)
+		}
+		actuals := make(map[string]OutputFile)
+		for _, o := range outputs {
+			rel, err := filepath.Rel(dst, o.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actuals[rel] = o
+		}
+		if len(actuals) != len(expecteds) {
+			t.Fatalf("[policy %s] unexpected outputs: expected=%v, actual=%v", policy, expecteds, actuals)
+		}
+		for _, expected := range expecteds {
+			if _, ok := actuals[expected]; !ok {
+				t.Fatalf("[policy %s] missing output '%s'", policy, expected)
+			}
+		}
+		if policy == SymlinkFollow {
+			if o := actuals["assets/page.html"]; string(o.data) != "<header>\n<h1 id=\"shared\">Shared</h1>\n" {
+				t.Fatalf("unexpected followed page: %s", o.data)
+			}
+		}
+	}
+
+	// Copied links are written as links with the same link targets
+	dst := filepath.Join(t.TempDir(), "dst")
+	err := Generate(src, dst, "TestSymlinks", "https://example.com", Symlinks(SymlinkCopy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, expected := range links {
+		if filepath.Base(expected)[0] == '_' {
+			_, err := os.Lstat(filepath.Join(dst, name))
+			if !os.IsNotExist(err) {
+				t.Fatalf("unexpected copied template '%s'", name)
+			}
+			continue
+		}
+		link, err := os.Readlink(filepath.Join(dst, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if link != expected {
+			t.Fatalf("unexpected link for '%s': expected=%s, actual=%s", name, expected, link)
+		}
+	}
+}
+
+func TestSymlinkCycle(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"self/src/a/index.md": "# A",
+		"mutual/src/index.md": "# Index",
+		"mutual/x/index.md":   "# X",
+		"mutual/y/index.md":   "# Y",
+	})
+	links := map[string]string{
+		"self/src/a/b":   filepath.Join(root, "self/src/a"),
+		"mutual/src/x":   filepath.Join(root, "mutual/x"),
+		"mutual/x/to-y":  filepath.Join(root, "mutual/y"),
+		"mutual/y/to-x":  filepath.Join(root, "mutual/x"),
+		"mutual/y/to-ok": filepath.Join(root, "mutual/src/index.md"),
+	}
+	for name, link := range links {
+		err := os.Symlink(link, filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, site := range []string{"self", "mutual"} {
+		src := filepath.Join(root, site, "src")
+		dst := filepath.Join(root, site, "dst")
+		_, _, err := Build(src, dst, "TestSymlinkCycle", "https://example.com", nil, Symlinks(SymlinkFollow))
+		if !errors.Is(err, ErrSymlinkCycle) {
+			t.Fatalf("[%s] unexpected error: %v", site, err)
+		}
+	}
+
+	// Cycles are reported once, and the rest of the site is built
+	src := filepath.Join(root, "mutual", "src")
+	dst := filepath.Join(root, "mutual", "dst")
+	_, outputs, err := Build(src, dst, "TestSymlinkCycle", "https://example.com", nil, Symlinks(SymlinkFollow), ContinueOnError(true))
+	var errs BuildErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Path != filepath.Join(src, "x", "to-y", "to-x") {
+		t.Fatalf("unexpected errors: %v", err)
+	}
+	if len(outputs) != 4 {
+		t.Fatalf("unexpected number of outputs: expected=4, actual=%d", len(outputs))
+	}
+}
+
+func TestSymlinkCopyIndex(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"shared/about.html":        "<h1>About us</h1>",
+		"shared/assets/a.css":      "body {}",
+		"shared/manual/index.html": "<h1>Manual</h1>",
+		"src/_header.html":         "",
+		"src/_footer.html":         "",
+		"src/docs/guide.md":        "# Guide",
+	})
+	links := map[string]string{
+		"src/docs/about.html": filepath.Join(root, "shared/about.html"),
+		"src/docs/assets":     filepath.Join(root, "shared/assets"),
+		"src/docs/manual":     filepath.Join(root, "shared/manual"),
+	}
+	for name, link := range links {
+		err := os.Symlink(link, filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	src := filepath.Join(root, "src")
+	dst := filepath.Join(root, "dst")
+	_, outputs, err := Build(src, dst, "TestSymlinkCopyIndex", "https://example.com", nil, Symlinks(SymlinkCopy), AutoIndex(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var index string
+	for _, o := range outputs {
+		if o.target == filepath.Join(dst, "docs", "index.html") {
+			index = string(o.data)
+		}
+	}
+	for _, expected := range []string{
+		`<a href="manual/">manual/</a>`,
+		`<a href="about.html">About us</a>`,
+		`<a href="guide.html">Guide</a>`,
+	} {
+		if !strings.Contains(index, expected) {
+			t.Fatalf("missing '%s' in index:\n%s", expected, index)
+		}
+	}
+	if strings.Contains(index, "assets/") {
+		t.Fatalf("unexpected linked directory without index.html in index:\n%s", index)
+	}
+
+	if SymlinkFollow.String() != "follow" || SymlinkPolicy(9).String() != "SymlinkPolicy(9)" {
+		t.Fatalf("unexpected policy strings: %s, %s", SymlinkFollow, SymlinkPolicy(9))
+	}
+}
+
+func TestSymlinkPreferredHTML(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"shared/foo.html":  "<h1>Linked</h1>",
+		"src/_header.html": "",
+		"src/_footer.html": "",
+		"src/foo.md":       "# Foo",
+		"src/bar.md":       "# Bar",
+	})
+	links := map[string]string{
+		"src/foo.html": filepath.Join(root, "shared/foo.html"),
+		"src/bar.html": filepath.Join(root, "shared/missing.html"),
+	}
+	for name, link := range links {
+		err := os.Symlink(link, filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	converted := func(name string) string {
+		return "<h1 id=\"" + name + "\">" + strings.ToUpper(name[:1]) + name[1:] + "</h1>\n"
+	}
+	tests := map[SymlinkPolicy]map[string]string{
+		// Ignored and broken links do not shadow pages
+		SymlinkIgnore: {"foo.html": converted("foo"), "bar.html": converted("bar")},
+		SymlinkFollow: {"foo.html": "<h1>Linked</h1>", "foo.md": "# Foo", "bar.html": converted("bar")},
+	}
+	src := filepath.Join(root, "src")
+	for policy, expecteds := range tests {
+		dst := filepath.Join(t.TempDir(), "dst")
+		_, outputs, err := Build(src, dst, "TestSymlinkPreferredHTML", "https://example.com", nil, Symlinks(policy))
+		if err != nil {
+			t.Fatalf("[policy %s] unexpected error: %v", policy, err)
+		}
+		if len(outputs) != len(expecteds) {
+			t.Fatalf("[policy %s] unexpected number of outputs: expected=%d, actual=%d", policy, len(expecteds), len(outputs))
+		}
+		for _, o := range outputs {
+			rel, err := filepath.Rel(dst, o.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected, ok := expecteds[rel]; !ok || string(o.data) != expected {
+				t.Fatalf("[policy %s] unexpected output '%s':\n%s", policy, rel, o.data)
+			}
+		}
+	}
+}
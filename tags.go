@@ -0,0 +1,210 @@
+package ssg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DirectiveTags sets comma-separated page tags, e.g. ":ssg-tags go, web dev"
+const DirectiveTags = ":ssg-tags "
+
+// TagFeedFile is the filename of per-tag RSS feeds
+const TagFeedFile = "feed.xml"
+
+// tag is a tag and its tagged pages collected during a build
+type tag struct {
+	name  string
+	pages []taggedPage
+}
+
+// taggedPage is a page listed in a tag page
+type taggedPage struct {
+	title string
+	rel   string // URL path relative to dst, e.g. blog/post.html or blog/post/
+	date  time.Time
+}
+
+// Slugify returns s in lowercase, with runs of characters other than
+// letters, digits and combining marks replaced by a single "-".
+func Slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
+
+// addTagged records page with output target under its tags
+func (s *Ssg) addTagged(target string, title []byte, page directives) error {
+	if s.options.tags == "" || len(page.tags) == 0 {
+		return nil
+	}
+
+	rel, err := filepath.Rel(s.Dst, target)
+	if err != nil {
+		return err
+	}
+	rel = filepath.ToSlash(rel)
+	p := taggedPage{title: string(title), rel: strings.TrimSuffix(rel, "index.html")}
+	if p.title == "" {
+		p.title = rel
+	}
+	if page.date != "" {
+		p.date, err = ParseDate(page.date)
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.tags == nil {
+		s.tags = make(map[string]*tag)
+	}
+	for _, name := range page.tags {
+		slug := Slugify(name)
+		if slug == "" {
+			return fmt.Errorf("tag '%s' has empty slug", name)
+		}
+		t, ok := s.tags[slug]
+		if !ok {
+			t = &tag{name: name}
+			s.tags[slug] = t
+		}
+		t.pages = append(t.pages, p)
+	}
+	return nil
+}
+
+// taxonomy generates the tag index and a page for each tag,
+// and per-tag feeds if enabled
+func (s *Ssg) taxonomy() ([]OutputFile, error) {
+	if s.options.tags == "" || len(s.tags) == 0 {
+		return nil, nil
+	}
+
+	dir := filepath.Join(s.Src, s.options.tags)
+	slugs := sortedKeys(s.tags)
+
+	// Taxonomy pages have no source directory,
+	// so they cascade templates from the root of src
+	layout := directives{layout: s.options.tagsLayout, templates: s.Src}
+
+	index := bytes.NewBufferString("<h1>Tags</h1>\n<ul class=\"ssg-tags\">\n")
+	outputs := make([]OutputFile, 0, len(slugs)+1)
+	for _, slug := range slugs {
+		// Tag pages link to tagged pages relative to the root of dst
+		root, err := filepath.Rel(filepath.Join(s.options.tags, slug), ".")
+		if err != nil {
+			return nil, err
+		}
+		root = filepath.ToSlash(root) + "/"
+
+		t := s.tags[slug]
+		sortTagged(t.pages)
+		Fprintf(index, "<li><a href=\"%s/\">%s</a> (%d)</li>\n", slug, html.EscapeString(t.name), len(t.pages))
+
+		buf := bytes.NewBuffer(nil)
+		Fprintf(buf, "<h1>%s</h1>\n<ul class=\"ssg-tag\">\n", html.EscapeString(t.name))
+		for _, p := range t.pages {
+			Fprint(buf, "<li>")
+			if !p.date.IsZero() {
+				date := p.date.Format(time.DateOnly)
+				Fprintf(buf, "<time datetime=\"%s\">%s</time> ", date, date)
+			}
+			href := root + p.rel
+			Fprintf(buf, "<a href=\"%s\">%s</a></li>\n", html.EscapeString(href), html.EscapeString(p.title))
+		}
+		Fprint(buf, "</ul>\n")
+
+		path := filepath.Join(dir, slug, "index"+ExtFragment)
+		output, err := s.coreHTML(path, ExtFragment, Fragment{}, buf.Bytes(), layout, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate tag page for '%s': %w", t.name, err)
+		}
+		output.noSitemap = !s.sitemap(path)
+		outputs = append(outputs, output)
+
+		if s.options.tagsFeed {
+			feedPath := filepath.Join(dir, slug, TagFeedFile)
+			target, err := mirrorPath(s.Src, s.Dst, feedPath)
+			if err != nil {
+				return nil, err
+			}
+			feed := Output(target, feedPath, s.tagFeed(t), 0o644)
+			feed.noSitemap = true
+			outputs = append(outputs, feed)
+		}
+	}
+	Fprint(index, "</ul>\n")
+
+	path := filepath.Join(dir, "index"+ExtFragment)
+	output, err := s.coreHTML(path, ExtFragment, Fragment{}, index.Bytes(), layout, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate tag index: %w", err)
+	}
+	output.noSitemap = !s.sitemap(path)
+
+	return append([]OutputFile{output}, outputs...), nil
+}
+
+// tagFeed renders RSS 2.0 feed of pages tagged with t
+func (s *Ssg) tagFeed(t *tag) []byte {
+	url := strings.TrimSuffix(s.URL, "/")
+	escape := func(text string) string {
+		b := bytes.NewBuffer(nil)
+		_ = xml.EscapeText(b, []byte(text))
+		return b.String()
+	}
+
+	feed := bytes.NewBufferString(xml.Header)
+	Fprint(feed, "<rss version=\"2.0\">\n<channel>\n")
+	Fprintf(feed, "<title>%s: %s</title>\n", escape(s.Title), escape(t.name))
+	link := path.Join(filepath.ToSlash(s.options.tags), Slugify(t.name))
+	Fprintf(feed, "<link>%s/%s/</link>\n", escape(url), escape(link))
+	Fprintf(feed, "<description>Pages tagged %s</description>\n", escape(t.name))
+	for _, p := range t.pages {
+		link := escape(url + "/" + p.rel)
+		Fprint(feed, "<item>\n")
+		Fprintf(feed, "<title>%s</title>\n<link>%s</link>\n<guid>%s</guid>\n", escape(p.title), link, link)
+		if !p.date.IsZero() {
+			Fprintf(feed, "<pubDate>%s</pubDate>\n", p.date.Format(time.RFC1123Z))
+		}
+		Fprint(feed, "</item>\n")
+	}
+	Fprint(feed, "</channel>\n</rss>\n")
+	return feed.Bytes()
+}
+
+// sortTagged sorts pages newest first, with undated pages last,
+// and ties broken by title then path
+func sortTagged(pages []taggedPage) {
+	sort.SliceStable(pages, func(i, j int) bool {
+		a, b := &pages[i], &pages[j]
+		if !a.date.Equal(b.date) {
+			if a.date.IsZero() || b.date.IsZero() {
+				return b.date.IsZero()
+			}
+			return a.date.After(b.date)
+		}
+		if a.title != b.title {
+			return a.title < b.title
+		}
+		return a.rel < b.rel
+	})
+}
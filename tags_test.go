@@ -0,0 +1,223 @@
+package ssg
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Go":              "go",
+		"Web Dev":         "web-dev",
+		"  C++ / Rust!! ": "c-rust",
+		"ภาษาไทย 2024":    "ภาษาไทย-2024",
+		"สวัสดี ชาวโลก":   "สวัสดี-ชาวโลก",
+		"---":             "",
+	}
+	for input, expected := range tests {
+		if actual := Slugify(input); actual != expected {
+			t.Fatalf("unexpected slug for '%s': expected='%s', actual='%s'", input, expected, actual)
+		}
+	}
+}
+
+func TestTags(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	writeFiles(t, src, map[string]string{
+		"_header.html":                   "<title>{{from-h1}}</title>\n",
+		"_footer.html":                   "",
+		"_layouts/tags.footer.html":      "<!-- TAGS FOOTER -->\n",
+		"index.md":                       "# Home",
+		"blog/a.md":                      ":ssg-date 2023-01-01\n:ssg-tags Go, Web Dev\n# Post A",
+		"blog/b.md":                      ":ssg-date 2024-01-01\n:ssg-tags go\n# Post <B>",
+		"blog/_header.html":              "<title>{{from-tag}}</title>\n",
+		"blog/c.md":                      ":ssg-tags web dev\n:ssg-title Post C\n# Heading C",
+		"notes/untagged.md":              "# Untagged",
+		"notes/_ssg.json":                `{"sitemap": false}`,
+		"notes/tagged-not-in-sitemap.md": ":ssg-tags Notes\n# Note",
+		"tags/_header.html":              "<!-- NOT FOR TAXONOMY -->\n",
+		"tags/about.md":                  "# About tags",
+	})
+
+	s := New(src, dst, "TestTags", "https://example.com/")
+	s.With(Caching(true), Tags("tags"), TagsLayout("tags"), TagsFeed(true))
+	_, outputs, err := s.Build(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expecteds := map[string]string{
+		"tags/index.html": `<title>Tags</title>
+<h1>Tags</h1>
+<ul class="ssg-tags">
+<li><a href="go/">Go</a> (2)</li>
+<li><a href="notes/">Notes</a> (1)</li>
+<li><a href="web-dev/">Web Dev</a> (2)</li>
+</ul>
+<!-- TAGS FOOTER -->
+`,
+		"tags/go/index.html": `<title>Go</title>
+<h1>Go</h1>
+<ul class="ssg-tag">
+<li><time datetime="2024-01-01">2024-01-01</time> <a href="../../blog/b.html">Post &lt;B&gt;</a></li>
+<li><time datetime="2023-01-01">2023-01-01</time> <a href="../../blog/a.html">Post A</a></li>
+</ul>
+<!-- TAGS FOOTER -->
+`,
+		"tags/web-dev/index.html": `<title>Web Dev</title>
+<h1>Web Dev</h1>
+<ul class="ssg-tag">
+<li><time datetime="2023-01-01">2023-01-01</time> <a href="../../blog/a.html">Post A</a></li>
+<li><a href="../../blog/c.html">Post C</a></li>
+</ul>
+<!-- TAGS FOOTER -->
+`,
+		"tags/go/feed.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+<channel>
+<title>TestTags: Go</title>
+<link>https://example.com/tags/go/</link>
+<description>Pages tagged Go</description>
+<item>
+<title>Post &lt;B&gt;</title>
+<link>https://example.com/blog/b.html</link>
+<guid>https://example.com/blog/b.html</guid>
+<pubDate>Mon, 01 Jan 2024 00:00:00 +0000</pubDate>
+</item>
+<item>
+<title>Post A</title>
+<link>https://example.com/blog/a.html</link>
+<guid>https://example.com/blog/a.html</guid>
+<pubDate>Sun, 01 Jan 2023 00:00:00 +0000</pubDate>
+</item>
+</channel>
+</rss>
+`,
+	}
+
+	found := make(map[string]bool)
+	for i := range outputs {
+		o := &outputs[i]
+		rel, err := filepath.Rel(dst, o.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rel == "blog/a.html" && strings.Contains(string(o.data), DirectiveTags) {
+			t.Fatalf("unexpected tags directive in '%s'", rel)
+		}
+		if rel == "tags/go/feed.xml" && o.originator != filepath.Join(src, "tags", "go", TagFeedFile) {
+			t.Fatalf("unexpected originator for '%s': '%s'", rel, o.originator)
+		}
+		expected, ok := expecteds[rel]
+		if !ok {
+			continue
+		}
+		found[rel] = true
+		if string(o.data) != expected {
+			t.Logf("expected:\n%s", expected)
+			t.Logf("actual:\n%s", o.data)
+			t.Fatalf("unexpected output data for '%s'", rel)
+		}
+	}
+	if len(found) != len(expecteds) {
+		t.Fatalf("unexpected number of taxonomy outputs: expected=%d, actual=%d", len(expecteds), len(found))
+	}
+
+	sitemap, err := Sitemap(dst, "https://example.com", time.Now(), outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, loc := range []string{"/tags/", "/tags/go/", "/tags/web-dev/"} {
+		if !strings.Contains(sitemap, "<loc>https://example.com"+loc) {
+			t.Fatalf("missing '%s' in sitemap:\n%s", loc, sitemap)
+		}
+	}
+	if strings.Contains(sitemap, "feed.xml") {
+		t.Fatalf("unexpected feed in sitemap:\n%s", sitemap)
+	}
+}
+
+func TestTagsRoot(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	writeFiles(t, src, map[string]string{
+		"_header.html": "",
+		"_footer.html": "",
+		"blog/a.md":    ":ssg-tags go\n# Post A",
+	})
+
+	_, outputs, err := Build(src, dst, "TestTagsRoot", "https://example.com", nil, Tags("."), TagsFeed(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	found := false
+	for i := range outputs {
+		o := &outputs[i]
+		rel, err := filepath.Rel(dst, o.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch rel {
+		case "go/index.html":
+			found = true
+			if !strings.Contains(string(o.data), `<a href="../blog/a.html">Post A</a>`) {
+				t.Fatalf("unexpected tag page:\n%s", o.data)
+			}
+		case "go/feed.xml":
+			if !strings.Contains(string(o.data), "<link>https://example.com/go/</link>") {
+				t.Fatalf("unexpected tag feed:\n%s", o.data)
+			}
+		}
+	}
+	if !found {
+		t.Fatal("missing tag page 'go/index.html'")
+	}
+}
+
+func TestTagsPrettyURLs(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	writeFiles(t, src, map[string]string{
+		"_header.html":  "",
+		"_footer.html":  "",
+		"index.md":      ":ssg-tags go\n# Home",
+		"blog/post.md":  ":ssg-tags go\n# Post",
+		"blog/index.md": ":ssg-tags go\n# Blog",
+	})
+
+	_, outputs, err := Build(src, dst, "TestTagsPrettyURLs", "https://example.com", nil, Tags("tags"), TagsFeed(true), PrettyURLs(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expecteds := map[string][]string{
+		"tags/go/index.html": {`href="../../blog/"`, `href="../../blog/post/"`, `href="../../"`},
+		"tags/go/feed.xml":   {"<link>https://example.com/blog/</link>", "<link>https://example.com/blog/post/</link>", "<link>https://example.com/</link>"},
+	}
+	for i := range outputs {
+		o := &outputs[i]
+		rel, err := filepath.Rel(dst, o.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, expected := range expecteds[rel] {
+			if !strings.Contains(string(o.data), expected) {
+				t.Fatalf("missing '%s' in '%s':\n%s", expected, rel, o.data)
+			}
+		}
+		if _, ok := expecteds[rel]; ok && strings.Contains(string(o.data), "index.html") {
+			t.Fatalf("unexpected index.html link in '%s':\n%s", rel, o.data)
+		}
+		delete(expecteds, rel)
+	}
+	if len(expecteds) != 0 {
+		t.Fatalf("missing outputs: %v", expecteds)
+	}
+}
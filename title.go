@@ -3,6 +3,7 @@ package ssg
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 )
 
 type TitleFrom uint8
@@ -69,15 +70,7 @@ func GetTitleFromTag(markdown []byte) []byte {
 // AddTitleFromH1 finds the first h1 in markdown and uses the h1 title
 // to write to <title> tag in header.
 func AddTitleFromH1(d []byte, header []byte, markdown []byte) []byte {
-	target := []byte(TargetFromH1)
-	title := GetTitleFromH1(markdown)
-	if len(title) == 0 {
-		header = bytes.Replace(header, target, d, 1)
-		return header
-	}
-
-	header = bytes.Replace(header, target, title, 1)
-	return header
+	return replaceTitle(d, header, []byte(TargetFromH1), GetTitleFromH1(markdown))
 }
 
 // AddTitleFromTag finds title in markdown and then write it to <title> tag in header.
@@ -136,6 +129,33 @@ func GetTitleFrom(b []byte) TitleFrom {
 	return TitleFromNone
 }
 
+// replaceTitle replaces target in header with title,
+// or with default title d if title is empty
+func replaceTitle(d []byte, header []byte, target []byte, title []byte) []byte {
+	if len(title) == 0 {
+		return bytes.Replace(header, target, d, 1)
+	}
+	return bytes.Replace(header, target, title, 1)
+}
+
+// retargetTitle rewrites title placeholder in header for title source from,
+// to the placeholder for title source to, and returns the effective title source.
+// If to is TitleFromNone, the placeholder is replaced with default title d.
+func retargetTitle(d []byte, header []byte, from TitleFrom, to TitleFrom) ([]byte, TitleFrom) {
+	targets := map[TitleFrom][]byte{
+		TitleFromH1:  []byte(TargetFromH1),
+		TitleFromTag: []byte(TargetFromTag),
+	}
+	target, ok := targets[from]
+	if !ok || from == to {
+		return header, from
+	}
+	if to == TitleFromNone {
+		return bytes.Replace(header, target, d, 1), to
+	}
+	return bytes.Replace(header, target, targets[to], 1), to
+}
+
 func trimRightWhitespace(b []byte) []byte {
 	return bytes.TrimRightFunc(b, func(r rune) bool {
 		switch r {
@@ -145,3 +165,17 @@ func trimRightWhitespace(b []byte) []byte {
 		return false
 	})
 }
+
+func (t *TitleFrom) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "h1":
+		*t = TitleFromH1
+	case "tag":
+		*t = TitleFromTag
+	case "none":
+		*t = TitleFromNone
+	default:
+		return fmt.Errorf("unknown title source '%s', expecting h1, tag or none", text)
+	}
+	return nil
+}